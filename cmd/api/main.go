@@ -3,6 +3,10 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/alexeybs90/go_bus_routes/internal/app"
 	"github.com/alexeybs90/go_bus_routes/internal/config"
@@ -14,10 +18,21 @@ func main() {
 		panic(err)
 	}
 
-	app := app.New(context.Background(), cfg)
-	err = app.Run()
-	if err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app := app.New(ctx, cfg)
+	go func() {
+		if err := app.Run(); err != nil {
+			fmt.Println(err.Error())
+		}
+		stop()
+	}()
+
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := app.Shutdown(shutdownCtx); err != nil {
 		fmt.Println(err.Error())
 	}
-
 }
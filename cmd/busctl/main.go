@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/alexeybs90/go_bus_routes/internal/config"
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/alexeybs90/go_bus_routes/internal/repository"
+	"github.com/alexeybs90/go_bus_routes/internal/services"
+	"github.com/alexeybs90/go_bus_routes/pkg/logger"
+	"github.com/alexeybs90/go_bus_routes/pkg/storage/postgresql"
+)
+
+const usage = `usage: busctl [-config path] <command> [args]
+
+commands:
+  import-gtfs [-dry-run] [-replace] feed.zip
+  export-netex [-o file.xml]
+`
+
+func main() {
+	configPath := flag.String("config", "config/local.yaml", "config file")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		panic(err)
+	}
+	log := logger.NewLogger(cfg.Env)
+	ctx := context.Background()
+
+	client, err := postgresql.NewClient(ctx, cfg.Storage)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+	service := services.New(repository.NewRepository(client, log), log, cfg.Journey, cfg.Fare, cfg.GTFS)
+
+	args := flag.Args()
+	switch args[0] {
+	case "import-gtfs":
+		err = importGTFS(ctx, service, args[1:])
+	case "export-netex":
+		err = exportNeTEx(ctx, service, args[1:])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+type gtfsImporter interface {
+	ImportGTFS(ctx context.Context, r io.ReaderAt, size int64, opts model.ImportOptions) (model.ImportReport, error)
+}
+
+func importGTFS(ctx context.Context, service gtfsImporter, args []string) error {
+	fs := flag.NewFlagSet("import-gtfs", flag.ExitOnError)
+	var opts model.ImportOptions
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "report what would be imported and roll back")
+	fs.BoolVar(&opts.Replace, "replace", false, "drop the current stations, routes and calendars first")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("import-gtfs: one feed file is required")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	report, err := service.ImportGTFS(ctx, f, info.Size(), opts)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+type netexExporter interface {
+	ExportNeTEx(ctx context.Context, w io.Writer) error
+}
+
+// exportNeTEx writes to stdout unless -o is given.
+func exportNeTEx(ctx context.Context, service netexExporter, args []string) error {
+	fs := flag.NewFlagSet("export-netex", flag.ExitOnError)
+	output := fs.String("o", "", "output file")
+	fs.Parse(args)
+
+	if *output == "" {
+		return service.ExportNeTEx(ctx, os.Stdout)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err = service.ExportNeTEx(ctx, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
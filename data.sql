@@ -1,15 +1,46 @@
+DROP TABLE IF EXISTS fare_zone CASCADE;
+DROP TABLE IF EXISTS fare_zone_price CASCADE;
 DROP TABLE IF EXISTS station CASCADE;
 DROP TABLE IF EXISTS route CASCADE;
 DROP TABLE IF EXISTS route_stations CASCADE;
 DROP TABLE IF EXISTS route_stations_time CASCADE;
+DROP TABLE IF EXISTS calendar CASCADE;
+DROP TABLE IF EXISTS calendar_date CASCADE;
+DROP TABLE IF EXISTS trip CASCADE;
+DROP TABLE IF EXISTS route_frequency CASCADE;
+DROP TABLE IF EXISTS route_frequency_offset CASCADE;
+DROP TABLE IF EXISTS station_transfer CASCADE;
+DROP TABLE IF EXISTS route_shape CASCADE;
+DROP TABLE IF EXISTS station_translation CASCADE;
+DROP TABLE IF EXISTS route_translation CASCADE;
+DROP SEQUENCE IF EXISTS station_version;
 
-CREATE TABLE public.station (
+CREATE TABLE public.fare_zone (
     id INTEGER NOT NULL GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
     name varchar (100)
 );
+-- prices are in kopecks
+CREATE TABLE public.fare_zone_price (
+    from_zone_id INTEGER NOT NULL,
+    to_zone_id INTEGER NOT NULL,
+    price INTEGER NOT NULL CHECK (price >= 0),
+    CONSTRAINT fare_zone_price_pk PRIMARY KEY (from_zone_id, to_zone_id),
+    CONSTRAINT from_zone_id_fk FOREIGN KEY (from_zone_id) REFERENCES public.fare_zone(id) ON DELETE CASCADE,
+    CONSTRAINT to_zone_id_fk FOREIGN KEY (to_zone_id) REFERENCES public.fare_zone(id) ON DELETE CASCADE
+);
+CREATE TABLE public.station (
+    id INTEGER NOT NULL GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name varchar (100),
+    lat DOUBLE PRECISION CHECK (lat BETWEEN -90 AND 90),
+    lon DOUBLE PRECISION CHECK (lon BETWEEN -180 AND 180),
+    zone_id INTEGER,
+    wheelchair SMALLINT NOT NULL DEFAULT 0 CHECK (wheelchair BETWEEN 0 AND 2), -- 0 unknown, 1 accessible, 2 not accessible
+    CONSTRAINT zone_id_fk FOREIGN KEY (zone_id) REFERENCES public.fare_zone(id) ON DELETE SET NULL
+);
 CREATE TABLE public.route (
     id INTEGER NOT NULL GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
-    name varchar (100)
+    name varchar (100),
+    price_per_km INTEGER CHECK (price_per_km >= 0) -- kopecks, NULL is no distance component
 );
 CREATE TABLE public.route_stations (
     id INTEGER NOT NULL GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
@@ -20,26 +51,140 @@ CREATE TABLE public.route_stations (
     CONSTRAINT station_id_fk FOREIGN KEY (station_id) REFERENCES public.station(id),
     CONSTRAINT route_station_unique UNIQUE (route_id, station_id)
 );
+CREATE TABLE public.calendar (
+    id INTEGER NOT NULL GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name varchar (100),
+    monday BOOLEAN NOT NULL DEFAULT false,
+    tuesday BOOLEAN NOT NULL DEFAULT false,
+    wednesday BOOLEAN NOT NULL DEFAULT false,
+    thursday BOOLEAN NOT NULL DEFAULT false,
+    friday BOOLEAN NOT NULL DEFAULT false,
+    saturday BOOLEAN NOT NULL DEFAULT false,
+    sunday BOOLEAN NOT NULL DEFAULT false,
+    start_date DATE,
+    end_date DATE
+);
+CREATE TABLE public.calendar_date (
+    id INTEGER NOT NULL GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    calendar_id INTEGER NOT NULL,
+    date DATE NOT NULL,
+    added BOOLEAN NOT NULL,
+    CONSTRAINT calendar_id_fk FOREIGN KEY (calendar_id) REFERENCES public.calendar(id) ON DELETE CASCADE,
+    CONSTRAINT calendar_date_unique UNIQUE (calendar_id, date)
+);
+CREATE TABLE public.trip (
+    id INTEGER NOT NULL GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    route_id INTEGER NOT NULL,
+    queue INTEGER NOT NULL,
+    calendar_id INTEGER, -- NULL runs every day
+    headsign varchar (100),
+    wheelchair SMALLINT NOT NULL DEFAULT 0 CHECK (wheelchair BETWEEN 0 AND 2), -- 0 unknown, 1 accessible, 2 not accessible
+    CONSTRAINT route_id_fk FOREIGN KEY (route_id) REFERENCES public.route(id),
+    CONSTRAINT calendar_id_fk FOREIGN KEY (calendar_id) REFERENCES public.calendar(id) ON DELETE RESTRICT,
+    CONSTRAINT route_queue_unique UNIQUE (route_id, queue)
+);
 CREATE TABLE public.route_stations_time (
     id INTEGER NOT NULL GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
     route_station_id INTEGER,
-    queue INTEGER,
-    stop_time TIME NOT NULL,
+    trip_id INTEGER NOT NULL,
+    stop_time INTERVAL NOT NULL CHECK (stop_time >= '00:00:00' AND stop_time < '48:00:00'), -- 24:25:00 is 00:25 of the next day
     CONSTRAINT route_station_id_fk FOREIGN KEY (route_station_id) REFERENCES public.route_stations(id),
-    CONSTRAINT route_station_queue_unique UNIQUE (route_station_id, queue)
+    CONSTRAINT trip_id_fk FOREIGN KEY (trip_id) REFERENCES public.trip(id) ON DELETE CASCADE,
+    CONSTRAINT route_station_trip_unique UNIQUE (route_station_id, trip_id)
+);
+-- runs every headway minutes from start_time until end_time
+CREATE TABLE public.route_frequency (
+    id INTEGER NOT NULL GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    route_id INTEGER NOT NULL,
+    calendar_id INTEGER, -- NULL runs every day
+    start_time INTERVAL NOT NULL,
+    end_time INTERVAL NOT NULL,
+    headway INTEGER NOT NULL CHECK (headway > 0),
+    wheelchair SMALLINT NOT NULL DEFAULT 0 CHECK (wheelchair BETWEEN 0 AND 2), -- 0 unknown, 1 accessible, 2 not accessible
+    CONSTRAINT route_id_fk FOREIGN KEY (route_id) REFERENCES public.route(id),
+    CONSTRAINT calendar_id_fk FOREIGN KEY (calendar_id) REFERENCES public.calendar(id) ON DELETE RESTRICT
 );
+CREATE TABLE public.route_frequency_offset (
+    frequency_id INTEGER NOT NULL,
+    route_station_id INTEGER NOT NULL,
+    minutes INTEGER NOT NULL,
+    CONSTRAINT route_frequency_offset_pk PRIMARY KEY (frequency_id, route_station_id),
+    CONSTRAINT frequency_id_fk FOREIGN KEY (frequency_id) REFERENCES public.route_frequency(id) ON DELETE CASCADE,
+    CONSTRAINT route_station_id_fk FOREIGN KEY (route_station_id) REFERENCES public.route_stations(id)
+);
+-- one-way walk between nearby stations
+CREATE TABLE public.station_transfer (
+    id INTEGER NOT NULL GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    from_station_id INTEGER NOT NULL,
+    to_station_id INTEGER NOT NULL,
+    walk_minutes INTEGER NOT NULL CHECK (walk_minutes > 0),
+    CONSTRAINT from_station_id_fk FOREIGN KEY (from_station_id) REFERENCES public.station(id) ON DELETE CASCADE,
+    CONSTRAINT to_station_id_fk FOREIGN KEY (to_station_id) REFERENCES public.station(id) ON DELETE CASCADE,
+    CONSTRAINT station_transfer_unique UNIQUE (from_station_id, to_station_id),
+    CONSTRAINT station_transfer_check CHECK (from_station_id <> to_station_id)
+);
+-- path of the route on the map, points in seq order
+CREATE TABLE public.route_shape (
+    route_id INTEGER NOT NULL,
+    seq INTEGER NOT NULL,
+    lat DOUBLE PRECISION NOT NULL CHECK (lat BETWEEN -90 AND 90),
+    lon DOUBLE PRECISION NOT NULL CHECK (lon BETWEEN -180 AND 180),
+    CONSTRAINT route_shape_pk PRIMARY KEY (route_id, seq),
+    CONSTRAINT route_id_fk FOREIGN KEY (route_id) REFERENCES public.route(id) ON DELETE CASCADE
+);
+CREATE TABLE public.station_translation (
+    station_id INTEGER NOT NULL,
+    lang varchar (16) NOT NULL,
+    name varchar (100) NOT NULL,
+    CONSTRAINT station_translation_pk PRIMARY KEY (station_id, lang),
+    CONSTRAINT station_id_fk FOREIGN KEY (station_id) REFERENCES public.station(id) ON DELETE CASCADE
+);
+CREATE TABLE public.route_translation (
+    route_id INTEGER NOT NULL,
+    lang varchar (16) NOT NULL,
+    name varchar (100) NOT NULL,
+    CONSTRAINT route_translation_pk PRIMARY KEY (route_id, lang),
+    CONSTRAINT route_id_fk FOREIGN KEY (route_id) REFERENCES public.route(id) ON DELETE CASCADE
+);
+-- station_version moves on with every write to the stations, the station
+-- search rebuilds its index when it changes
+CREATE SEQUENCE public.station_version;
+CREATE OR REPLACE FUNCTION public.bump_station_version() RETURNS trigger AS $$
+BEGIN
+    PERFORM nextval('public.station_version');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER station_version AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON public.station
+    FOR EACH STATEMENT EXECUTE FUNCTION public.bump_station_version();
+CREATE TRIGGER station_version AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON public.station_translation
+    FOR EACH STATEMENT EXECUTE FUNCTION public.bump_station_version();
 
+ALTER SEQUENCE fare_zone_id_seq RESTART WITH 1;
 ALTER SEQUENCE route_id_seq RESTART WITH 1;
 ALTER SEQUENCE station_id_seq RESTART WITH 1;
 ALTER SEQUENCE route_stations_id_seq RESTART WITH 1;
 ALTER SEQUENCE route_stations_time_id_seq RESTART WITH 1;
+ALTER SEQUENCE calendar_id_seq RESTART WITH 1;
+ALTER SEQUENCE calendar_date_id_seq RESTART WITH 1;
+ALTER SEQUENCE trip_id_seq RESTART WITH 1;
+ALTER SEQUENCE route_frequency_id_seq RESTART WITH 1;
+ALTER SEQUENCE station_transfer_id_seq RESTART WITH 1;
+
+INSERT INTO fare_zone (name) VALUES ('Город');
+INSERT INTO fare_zone (name) VALUES ('Пригород');
+
+INSERT INTO fare_zone_price (from_zone_id, to_zone_id, price) VALUES (1, 1, 7000);
+INSERT INTO fare_zone_price (from_zone_id, to_zone_id, price) VALUES (1, 2, 9000);
+INSERT INTO fare_zone_price (from_zone_id, to_zone_id, price) VALUES (2, 1, 9000);
+INSERT INTO fare_zone_price (from_zone_id, to_zone_id, price) VALUES (2, 2, 7000);
 
-INSERT INTO station (name) VALUES ('м. Купчино');
-INSERT INTO station (name) VALUES ('м. Московская');
-INSERT INTO station (name) VALUES ('м. Технологический институт');
-INSERT INTO station (name) VALUES ('Невский проспект 110');
-INSERT INTO station (name) VALUES ('Пулково');
-INSERT INTO station (name) VALUES ('Пулковское шоссе 10');
+INSERT INTO station (name, lat, lon, zone_id, wheelchair) VALUES ('м. Купчино', 59.829887, 30.375399, 1, 1);
+INSERT INTO station (name, lat, lon, zone_id, wheelchair) VALUES ('м. Московская', 59.851677, 30.321811, 1, 1);
+INSERT INTO station (name, lat, lon, zone_id, wheelchair) VALUES ('м. Технологический институт', 59.916799, 30.318967, 1, 2);
+INSERT INTO station (name, lat, lon, zone_id, wheelchair) VALUES ('Невский проспект 110', 59.93251, 30.36068, 1, 1);
+INSERT INTO station (name, lat, lon, zone_id, wheelchair) VALUES ('Пулково', 59.800292, 30.262503, 2, 1);
+INSERT INTO station (name, lat, lon, zone_id, wheelchair) VALUES ('Пулковское шоссе 10', 59.83554, 30.32324, 1, 1);
 
 INSERT INTO route (name) VALUES ('Автобус № 1 Купчино-Невский');
 INSERT INTO route (name) VALUES ('Автобус № 1 Невский-Купчино');
@@ -64,12 +209,45 @@ INSERT INTO route_stations (route_id, station_id, pos) VALUES (4, 2, 0);
 INSERT INTO route_stations (route_id, station_id, pos) VALUES (4, 6, 1);
 INSERT INTO route_stations (route_id, station_id, pos) VALUES (4, 5, 2);
 
-INSERT INTO route_stations_time (route_station_id, queue, stop_time) VALUES (1, 0, '08:00:00');
-INSERT INTO route_stations_time (route_station_id, queue, stop_time) VALUES (2, 0, '08:15:00');
-INSERT INTO route_stations_time (route_station_id, queue, stop_time) VALUES (3, 0, '08:30:00');
-INSERT INTO route_stations_time (route_station_id, queue, stop_time) VALUES (4, 0, '08:45:00');
+INSERT INTO station_translation (station_id, lang, name) VALUES (1, 'en', 'Kupchino metro');
+INSERT INTO station_translation (station_id, lang, name) VALUES (2, 'en', 'Moskovskaya metro');
+INSERT INTO station_translation (station_id, lang, name) VALUES (3, 'en', 'Tekhnologichesky Institut metro');
+INSERT INTO station_translation (station_id, lang, name) VALUES (4, 'en', 'Nevsky Prospekt 110');
+INSERT INTO station_translation (station_id, lang, name) VALUES (5, 'en', 'Pulkovo Airport');
+INSERT INTO station_translation (station_id, lang, name) VALUES (6, 'en', 'Pulkovskoye Shosse 10');
+
+INSERT INTO route_translation (route_id, lang, name) VALUES (1, 'en', 'Bus 1 Kupchino - Nevsky');
+INSERT INTO route_translation (route_id, lang, name) VALUES (2, 'en', 'Bus 1 Nevsky - Kupchino');
+INSERT INTO route_translation (route_id, lang, name) VALUES (3, 'en', 'Bus 2 Pulkovo - Moskovskaya');
+INSERT INTO route_translation (route_id, lang, name) VALUES (4, 'en', 'Bus 2 Moskovskaya - Pulkovo');
+
+INSERT INTO calendar (name, monday, tuesday, wednesday, thursday, friday, saturday, sunday)
+    VALUES ('Будни', true, true, true, true, true, false, false);
+INSERT INTO calendar (name, monday, tuesday, wednesday, thursday, friday, saturday, sunday)
+    VALUES ('Выходные', false, false, false, false, false, true, true);
+
+INSERT INTO calendar_date (calendar_id, date, added) VALUES (1, '2025-01-01', false);
+
+INSERT INTO trip (route_id, queue, calendar_id, wheelchair) VALUES (1, 0, NULL, 1);
+INSERT INTO trip (route_id, queue, calendar_id) VALUES (1, 1, 1);
+
+INSERT INTO route_stations_time (route_station_id, trip_id, stop_time) VALUES (1, 1, '08:00:00');
+INSERT INTO route_stations_time (route_station_id, trip_id, stop_time) VALUES (2, 1, '08:15:00');
+INSERT INTO route_stations_time (route_station_id, trip_id, stop_time) VALUES (3, 1, '08:30:00');
+INSERT INTO route_stations_time (route_station_id, trip_id, stop_time) VALUES (4, 1, '08:45:00');
+
+INSERT INTO route_stations_time (route_station_id, trip_id, stop_time) VALUES (1, 2, '20:00:00');
+INSERT INTO route_stations_time (route_station_id, trip_id, stop_time) VALUES (2, 2, '20:15:00');
+INSERT INTO route_stations_time (route_station_id, trip_id, stop_time) VALUES (3, 2, '20:30:00');
+INSERT INTO route_stations_time (route_station_id, trip_id, stop_time) VALUES (4, 2, '20:45:00');
+
+INSERT INTO route_frequency (route_id, calendar_id, start_time, end_time, headway, wheelchair) VALUES (4, 1, '07:00:00', '10:00:00', 10, 1);
+
+INSERT INTO route_frequency_offset (frequency_id, route_station_id, minutes) VALUES (1, 12, 0);
+INSERT INTO route_frequency_offset (frequency_id, route_station_id, minutes) VALUES (1, 13, 7);
+INSERT INTO route_frequency_offset (frequency_id, route_station_id, minutes) VALUES (1, 14, 15);
 
-INSERT INTO route_stations_time (route_station_id, queue, stop_time) VALUES (1, 1, '20:00:00');
-INSERT INTO route_stations_time (route_station_id, queue, stop_time) VALUES (2, 1, '20:15:00');
-INSERT INTO route_stations_time (route_station_id, queue, stop_time) VALUES (3, 1, '20:30:00');
-INSERT INTO route_stations_time (route_station_id, queue, stop_time) VALUES (4, 1, '20:45:00');
\ No newline at end of file
+INSERT INTO route_shape (route_id, seq, lat, lon) VALUES (3, 0, 59.800292, 30.262503);
+INSERT INTO route_shape (route_id, seq, lat, lon) VALUES (3, 1, 59.81297, 30.30442);
+INSERT INTO route_shape (route_id, seq, lat, lon) VALUES (3, 2, 59.83554, 30.32324);
+INSERT INTO route_shape (route_id, seq, lat, lon) VALUES (3, 3, 59.851677, 30.321811);
@@ -2,12 +2,14 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 
 	"github.com/alexeybs90/go_bus_routes/internal/config"
 	"github.com/alexeybs90/go_bus_routes/internal/handlers"
+	"github.com/alexeybs90/go_bus_routes/internal/realtime"
 	"github.com/alexeybs90/go_bus_routes/internal/repository"
 	"github.com/alexeybs90/go_bus_routes/internal/services"
 	"github.com/alexeybs90/go_bus_routes/pkg/logger"
@@ -22,6 +24,10 @@ type App struct {
 	logger logger.Logger
 	cfg    config.Config
 	server *http.Server
+	poller *realtime.Poller
+	// stopPoller ends the poller started by Run
+	stopPoller context.CancelFunc
+	pollerCtx  context.Context
 }
 
 func New(ctx context.Context, cfg config.Config) *App {
@@ -37,7 +43,17 @@ func New(ctx context.Context, cfg config.Config) *App {
 	}
 
 	repo := repository.NewRepository(client, log)
-	service := services.New(repo, log)
+	service := services.New(repo, log, cfg.Journey, cfg.Fare, cfg.GTFS)
+
+	store := realtime.NewStore()
+	service.SetRealtime(store)
+	var poller *realtime.Poller
+	if cfg.Realtime.URL != "" || cfg.Realtime.File != "" {
+		poller, err = realtime.NewPoller(cfg.Realtime, cfg.GTFS.Location(), store, log)
+		if err != nil {
+			log.Error(err.Error())
+		}
+	}
 
 	router := chi.NewRouter()
 
@@ -46,7 +62,7 @@ func New(ctx context.Context, cfg config.Config) *App {
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.URLFormat)
 
-	handler := handlers.NewHandler(repo, log, service)
+	handler := handlers.NewHandler(repo, log, service, cfg.GTFS.Location())
 	handler.Register(router)
 
 	server := &http.Server{
@@ -57,20 +73,40 @@ func New(ctx context.Context, cfg config.Config) *App {
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
+	pollerCtx, stopPoller := context.WithCancel(ctx)
+
 	return &App{
-		logger: log,
-		db:     client,
-		cfg:    cfg,
-		server: server,
+		logger:     log,
+		db:         client,
+		cfg:        cfg,
+		server:     server,
+		poller:     poller,
+		stopPoller: stopPoller,
+		pollerCtx:  pollerCtx,
 	}
 }
 
 func (app *App) Run() error {
+	if app.poller != nil {
+		go app.poller.Run(app.pollerCtx)
+	}
 	app.logger.Info("starting server", slog.String("address", app.cfg.Server.Address))
 	err := app.server.ListenAndServe()
-	if err != nil {
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		app.logger.Error(err.Error())
 		return fmt.Errorf("failed to start server: %w", err)
 	}
 	return nil
 }
+
+// Shutdown stops the realtime poller and the server, waiting for running
+// requests until ctx is done.
+func (app *App) Shutdown(ctx context.Context) error {
+	app.stopPoller()
+	app.logger.Info("stopping server")
+	err := app.server.Shutdown(ctx)
+	if app.db != nil {
+		app.db.Close()
+	}
+	return err
+}
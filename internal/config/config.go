@@ -10,9 +10,13 @@ import (
 )
 
 type Config struct {
-	Env     string  `yaml:"env" env-default:"local"`
-	Server  Server  `yaml:"server"`
-	Storage Storage `yaml:"storage"`
+	Env      string   `yaml:"env" env-default:"local"`
+	Server   Server   `yaml:"server"`
+	Storage  Storage  `yaml:"storage"`
+	Journey  Journey  `yaml:"journey"`
+	Fare     Fare     `yaml:"fare"`
+	GTFS     GTFS     `yaml:"gtfs"`
+	Realtime Realtime `yaml:"realtime"`
 }
 
 type Server struct {
@@ -29,6 +33,46 @@ type Storage struct {
 	Dbname   string `yaml:"dbname"`
 }
 
+type Journey struct {
+	MaxTransfers int `yaml:"max_transfers" env-default:"2"`
+	// WalkSpeed in meters per minute, used to suggest walking transfers
+	WalkSpeed float64 `yaml:"walk_speed" env-default:"75"`
+	// WalkRadius in meters, stations further apart are never suggested
+	WalkRadius float64 `yaml:"walk_radius" env-default:"400"`
+}
+
+type Fare struct {
+	Currency string `yaml:"currency" env-default:"RUB"`
+}
+
+// GTFS describes the operator in exported feeds, Codespace prefixes the
+// object ids of NeTEx exports
+type GTFS struct {
+	AgencyName string `yaml:"agency_name" env-default:"Bus routes"`
+	AgencyURL  string `yaml:"agency_url" env-default:"http://localhost:8080"`
+	Timezone   string `yaml:"timezone" env-default:"Europe/Moscow"`
+	Lang       string `yaml:"lang" env-default:"ru"`
+	Codespace  string `yaml:"codespace" env-default:"BUS"`
+
+	location *time.Location
+}
+
+// Location is the loaded Timezone, service days start at its midnight.
+func (g GTFS) Location() *time.Location {
+	if g.location == nil {
+		return time.Local
+	}
+	return g.location
+}
+
+// Realtime reads a GTFS-realtime feed from URL or File, with neither set
+// there are no realtime updates
+type Realtime struct {
+	URL      string        `yaml:"url"`
+	File     string        `yaml:"file"`
+	Interval time.Duration `yaml:"interval" env-default:"30s"`
+}
+
 func LoadConfig(path string) (Config, error) {
 	if path == "" {
 		return Config{}, errors.New("config path is not set")
@@ -44,5 +88,11 @@ func LoadConfig(path string) (Config, error) {
 		return Config{}, fmt.Errorf("cannot read config: %s", path)
 	}
 
+	loc, err := time.LoadLocation(cfg.GTFS.Timezone)
+	if err != nil {
+		return Config{}, fmt.Errorf("wrong timezone %q: %w", cfg.GTFS.Timezone, err)
+	}
+	cfg.GTFS.location = loc
+
 	return cfg, nil
 }
@@ -0,0 +1,432 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+const (
+	stopsFile         = "stops.txt"
+	routesFile        = "routes.txt"
+	tripsFile         = "trips.txt"
+	stopTimesFile     = "stop_times.txt"
+	calendarFile      = "calendar.txt"
+	calendarDatesFile = "calendar_dates.txt"
+
+	dateLayout = "20060102"
+	// maxName is the length of the name columns in the schema
+	maxName = 100
+	// maxWarnings keeps the report readable for badly broken feeds
+	maxWarnings = 100
+)
+
+type warnings struct {
+	list    []string
+	dropped int
+}
+
+func (w *warnings) add(format string, args ...any) {
+	if len(w.list) >= maxWarnings {
+		w.dropped++
+		return
+	}
+	w.list = append(w.list, fmt.Sprintf(format, args...))
+}
+
+func (w *warnings) result() []string {
+	if w.dropped > 0 {
+		return append(w.list, fmt.Sprintf("%d more warnings", w.dropped))
+	}
+	return w.list
+}
+
+type tripInfo struct {
+	routeId    string
+	calendar   int
+	headsign   string
+	wheelchair model.Wheelchair
+}
+
+type stopTime struct {
+	seq     int
+	station int
+	// time is -1 when the feed leaves it to interpolation
+	time int
+}
+
+type reader struct {
+	files     map[string]*zip.File
+	feed      *model.Feed
+	warnings  warnings
+	stations  map[string]int
+	calendars map[string]int
+	routes    map[string]string
+	trips     map[string]tripInfo
+	tripIds   []string
+	stopTimes map[string][]stopTime
+}
+
+// Read converts a GTFS zip into a feed. Rows the schema can not hold, like
+// trips visiting a stop twice, are skipped and described in the warnings.
+func Read(r io.ReaderAt, size int64) (*model.Feed, []string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	rd := &reader{
+		files:     zipFiles(zr),
+		feed:      &model.Feed{},
+		warnings:  warnings{list: make([]string, 0)},
+		stations:  make(map[string]int),
+		calendars: make(map[string]int),
+		routes:    make(map[string]string),
+		trips:     make(map[string]tripInfo),
+		stopTimes: make(map[string][]stopTime),
+	}
+
+	steps := []func() error{rd.readStops, rd.readCalendars, rd.readRoutes, rd.readTrips, rd.readStopTimes}
+	for _, step := range steps {
+		if err = step(); err != nil {
+			return nil, nil, err
+		}
+	}
+	rd.buildRoutes()
+	return rd.feed, rd.warnings.result(), nil
+}
+
+func (rd *reader) readStops() error {
+	parents := make(map[string]model.Wheelchair)
+	inherit := make(map[int]string)
+	err := readTable(rd.files, stopsFile, []string{"stop_id"}, func(line int, rec record) error {
+		id := rec.get("stop_id")
+		wheelchair := parseWheelchair(rec.get("wheelchair_boarding"))
+		switch rec.get("location_type") {
+		case "", "0":
+		case "1":
+			parents[id] = wheelchair
+			return nil
+		default:
+			return nil
+		}
+		if _, ok := rd.stations[id]; ok || id == "" {
+			rd.warnings.add("%s line %d: duplicate or empty stop_id %q, skipped", stopsFile, line, id)
+			return nil
+		}
+		lat, errLat := strconv.ParseFloat(rec.get("stop_lat"), 64)
+		lon, errLon := strconv.ParseFloat(rec.get("stop_lon"), 64)
+		if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			rd.warnings.add("%s line %d: stop %s has no valid position, skipped", stopsFile, line, id)
+			return nil
+		}
+		name := rec.get("stop_name")
+		if name == "" {
+			name = id
+		}
+
+		rd.stations[id] = len(rd.feed.Stations)
+		if parent := rec.get("parent_station"); parent != "" && wheelchair == model.WheelchairUnknown {
+			inherit[len(rd.feed.Stations)] = parent
+		}
+		rd.feed.Stations = append(rd.feed.Stations, model.Station{
+			Name:       truncate(name),
+			Lat:        &lat,
+			Lon:        &lon,
+			Wheelchair: wheelchair,
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// stops without their own wheelchair_boarding take it from the station
+	for i, parent := range inherit {
+		rd.feed.Stations[i].Wheelchair = parents[parent]
+	}
+	return nil
+}
+
+// readCalendars reads calendar.txt and calendar_dates.txt, a feed may have
+// only one of them.
+func (rd *reader) readCalendars() error {
+	days := []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+	required := append([]string{"service_id", "start_date", "end_date"}, days...)
+	errCalendar := readTable(rd.files, calendarFile, required, func(line int, rec record) error {
+		id := rec.get("service_id")
+		if _, ok := rd.calendars[id]; ok {
+			rd.warnings.add("%s line %d: duplicate service_id %q, skipped", calendarFile, line, id)
+			return nil
+		}
+		start, errStart := parseDate(rec.get("start_date"))
+		end, errEnd := parseDate(rec.get("end_date"))
+		if errStart != nil || errEnd != nil {
+			rd.warnings.add("%s line %d: service %s has wrong dates, skipped", calendarFile, line, id)
+			return nil
+		}
+		c := model.Calendar{
+			Name:      truncate(id),
+			Monday:    rec.get("monday") == "1",
+			Tuesday:   rec.get("tuesday") == "1",
+			Wednesday: rec.get("wednesday") == "1",
+			Thursday:  rec.get("thursday") == "1",
+			Friday:    rec.get("friday") == "1",
+			Saturday:  rec.get("saturday") == "1",
+			Sunday:    rec.get("sunday") == "1",
+			StartDate: start,
+			EndDate:   end,
+		}
+		rd.calendars[id] = len(rd.feed.Calendars)
+		rd.feed.Calendars = append(rd.feed.Calendars, c)
+		return nil
+	})
+	if errCalendar != nil && !errors.Is(errCalendar, errNoFile) {
+		return errCalendar
+	}
+
+	required = []string{"service_id", "date", "exception_type"}
+	errDates := readTable(rd.files, calendarDatesFile, required, func(line int, rec record) error {
+		id := rec.get("service_id")
+		date, err := parseDate(rec.get("date"))
+		if err != nil {
+			rd.warnings.add("%s line %d: wrong date %q, skipped", calendarDatesFile, line, rec.get("date"))
+			return nil
+		}
+		i, ok := rd.calendars[id]
+		if !ok {
+			i = len(rd.feed.Calendars)
+			rd.calendars[id] = i
+			rd.feed.Calendars = append(rd.feed.Calendars, model.Calendar{Name: truncate(id)})
+		}
+		c := &rd.feed.Calendars[i]
+		c.Exceptions = append(c.Exceptions, model.CalendarDate{Date: date, Added: rec.get("exception_type") == "1"})
+		return nil
+	})
+	if errDates != nil && !errors.Is(errDates, errNoFile) {
+		return errDates
+	}
+
+	if errCalendar != nil && errDates != nil {
+		return fmt.Errorf("%s or %s is required", calendarFile, calendarDatesFile)
+	}
+	return nil
+}
+
+func (rd *reader) readRoutes() error {
+	return readTable(rd.files, routesFile, []string{"route_id"}, func(line int, rec record) error {
+		id := rec.get("route_id")
+		name := rec.get("route_short_name")
+		if name == "" {
+			name = rec.get("route_long_name")
+		}
+		if name == "" {
+			name = id
+		}
+		rd.routes[id] = name
+		return nil
+	})
+}
+
+func (rd *reader) readTrips() error {
+	required := []string{"route_id", "service_id", "trip_id"}
+	return readTable(rd.files, tripsFile, required, func(line int, rec record) error {
+		id := rec.get("trip_id")
+		if _, ok := rd.trips[id]; ok {
+			rd.warnings.add("%s line %d: duplicate trip_id %q, skipped", tripsFile, line, id)
+			return nil
+		}
+		routeId := rec.get("route_id")
+		if _, ok := rd.routes[routeId]; !ok {
+			rd.warnings.add("%s line %d: trip %s has unknown route %s, skipped", tripsFile, line, id, routeId)
+			return nil
+		}
+		calendar, ok := rd.calendars[rec.get("service_id")]
+		if !ok {
+			rd.warnings.add("%s line %d: trip %s has unknown service %s, skipped", tripsFile, line, id, rec.get("service_id"))
+			return nil
+		}
+		rd.trips[id] = tripInfo{
+			routeId:    routeId,
+			calendar:   calendar,
+			headsign:   truncate(rec.get("trip_headsign")),
+			wheelchair: parseWheelchair(rec.get("wheelchair_accessible")),
+		}
+		rd.tripIds = append(rd.tripIds, id)
+		return nil
+	})
+}
+
+func (rd *reader) readStopTimes() error {
+	broken := make(map[string]bool)
+	required := []string{"trip_id", "stop_id", "stop_sequence"}
+	return readTable(rd.files, stopTimesFile, required, func(line int, rec record) error {
+		tripId := rec.get("trip_id")
+		if _, ok := rd.trips[tripId]; !ok || broken[tripId] {
+			return nil
+		}
+		seq, err := strconv.Atoi(rec.get("stop_sequence"))
+		if err != nil {
+			rd.warnings.add("%s line %d: trip %s has wrong stop_sequence, trip skipped", stopTimesFile, line, tripId)
+			broken[tripId] = true
+			delete(rd.stopTimes, tripId)
+			return nil
+		}
+		station, ok := rd.stations[rec.get("stop_id")]
+		if !ok {
+			rd.warnings.add("%s line %d: trip %s has unknown stop %s, trip skipped", stopTimesFile, line, tripId, rec.get("stop_id"))
+			broken[tripId] = true
+			delete(rd.stopTimes, tripId)
+			return nil
+		}
+		t := -1
+		if v := rec.get("departure_time"); v != "" {
+			t, err = model.ParseClock(v)
+		} else if v = rec.get("arrival_time"); v != "" {
+			t, err = model.ParseClock(v)
+		}
+		if err != nil {
+			rd.warnings.add("%s line %d: trip %s: %s, trip skipped", stopTimesFile, line, tripId, err)
+			broken[tripId] = true
+			delete(rd.stopTimes, tripId)
+			return nil
+		}
+		rd.stopTimes[tripId] = append(rd.stopTimes[tripId], stopTime{seq: seq, station: station, time: t})
+		return nil
+	})
+}
+
+// buildRoutes turns every distinct stop sequence of a GTFS route into one of
+// our routes, since a route here has a single station order.
+func (rd *reader) buildRoutes() {
+	patterns := make(map[string]int)
+	gtfsRoutes := make(map[int]string)
+	perRoute := make(map[string]int)
+
+	for _, tripId := range rd.tripIds {
+		stops, ok := rd.stopTimes[tripId]
+		if !ok {
+			continue
+		}
+		times, stations, reason := tripTimes(stops)
+		if reason != "" {
+			rd.warnings.add("trip %s %s, skipped", tripId, reason)
+			continue
+		}
+
+		info := rd.trips[tripId]
+		key := info.routeId + "|" + joinInts(stations)
+		i, ok := patterns[key]
+		if !ok {
+			i = len(rd.feed.Routes)
+			patterns[key] = i
+			gtfsRoutes[i] = info.routeId
+			perRoute[info.routeId]++
+			rd.feed.Routes = append(rd.feed.Routes, model.FeedRoute{Stations: stations})
+		}
+		route := &rd.feed.Routes[i]
+		route.Trips = append(route.Trips, model.FeedTrip{
+			Calendar:   info.calendar,
+			Headsign:   info.headsign,
+			Wheelchair: info.wheelchair,
+			Times:      times,
+		})
+	}
+
+	for i := range rd.feed.Routes {
+		route := &rd.feed.Routes[i]
+		name := rd.routes[gtfsRoutes[i]]
+		if perRoute[gtfsRoutes[i]] > 1 {
+			first := rd.feed.Stations[route.Stations[0]].Name
+			last := rd.feed.Stations[route.Stations[len(route.Stations)-1]].Name
+			name = fmt.Sprintf("%s (%s - %s)", name, first, last)
+		}
+		route.Name = truncate(name)
+		sort.SliceStable(route.Trips, func(a, b int) bool {
+			return route.Trips[a].Times[0] < route.Trips[b].Times[0]
+		})
+	}
+}
+
+// tripTimes orders the stop times of a trip and fills the times left out
+// by the feed, the reason is set when the trip can not be stored.
+func tripTimes(stops []stopTime) ([]int, []int, string) {
+	sort.Slice(stops, func(i, j int) bool { return stops[i].seq < stops[j].seq })
+	if len(stops) < 2 {
+		return nil, nil, "has less than two stops"
+	}
+	if stops[0].time < 0 || stops[len(stops)-1].time < 0 {
+		return nil, nil, "has no time at the first or last stop"
+	}
+
+	times := make([]int, len(stops))
+	stations := make([]int, len(stops))
+	seen := make(map[int]bool, len(stops))
+	prev := 0
+	for i, st := range stops {
+		if seen[st.station] {
+			return nil, nil, "visits a stop twice"
+		}
+		seen[st.station] = true
+		stations[i] = st.station
+
+		if st.time < 0 {
+			next := i + 1
+			for stops[next].time < 0 {
+				next++
+			}
+			// spread the missing times evenly between the known ones
+			from, to := stops[prev].time, stops[next].time
+			times[i] = from + (to-from)*(i-prev)/(next-prev)
+			continue
+		}
+		if i > 0 && st.time < times[i-1] {
+			return nil, nil, "goes back in time"
+		}
+		times[i] = st.time
+		prev = i
+	}
+	return times, stations, ""
+}
+
+func parseWheelchair(v string) model.Wheelchair {
+	switch v {
+	case "1":
+		return model.WheelchairAccessible
+	case "2":
+		return model.WheelchairNotAccessible
+	default:
+		return model.WheelchairUnknown
+	}
+}
+
+// parseDate converts a GTFS YYYYMMDD date into model.DateLayout.
+func parseDate(v string) (string, error) {
+	d, err := time.Parse(dateLayout, v)
+	if err != nil {
+		return "", err
+	}
+	return d.Format(model.DateLayout), nil
+}
+
+func truncate(s string) string {
+	runes := []rune(s)
+	if len(runes) > maxName {
+		return string(runes[:maxName])
+	}
+	return s
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
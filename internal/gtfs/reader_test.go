@@ -0,0 +1,225 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// feedZip zips the files, the lines of every file are joined with CRLF.
+func feedZip(t *testing.T, files map[string][]string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, lines := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = f.Write([]byte(strings.Join(lines, "\r\n") + "\r\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func readFeed(t *testing.T, files map[string][]string) (*model.Feed, []string) {
+	t.Helper()
+	r := feedZip(t, files)
+	feed, warnings, err := Read(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return feed, warnings
+}
+
+func hasWarning(warnings []string, part string) bool {
+	for _, w := range warnings {
+		if strings.Contains(w, part) {
+			return true
+		}
+	}
+	return false
+}
+
+var testFeed = map[string][]string{
+	stopsFile: {
+		"stop_id,stop_name,stop_lat,stop_lon,location_type,parent_station,wheelchair_boarding",
+		"ST,Central,59.9,30.3,1,,1",
+		"A,Central A,59.9,30.3,0,ST,",
+		"B,Bay,59.8,30.2,0,,2",
+		"C,Cape,59.7,30.1,0,,",
+		"D,Dock,59.6,30.0,0,,1",
+		"X,Nowhere,,,0,,",
+	},
+	calendarFile: {
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date",
+		"WK,1,1,1,1,1,0,0,20250101,20251231",
+	},
+	calendarDatesFile: {
+		"service_id,date,exception_type",
+		"WK,20250501,2",
+		"HOL,20250501,1",
+	},
+	routesFile: {
+		"route_id,route_short_name,route_long_name",
+		"R1,1,Central - Dock",
+	},
+	tripsFile: {
+		"route_id,service_id,trip_id,trip_headsign,wheelchair_accessible",
+		"R1,WK,late,Dock,1",
+		"R1,WK,early,Dock,",
+		"R1,HOL,back,Central,",
+		"R1,WK,broken,Dock,",
+		"R1,WK,short,Dock,",
+		"R1,NONE,lost,Dock,",
+	},
+	stopTimesFile: {
+		"trip_id,arrival_time,departure_time,stop_id,stop_sequence",
+		"late,09:00:00,09:00:00,A,1",
+		"late,,,B,2",
+		"late,,,C,3",
+		"late,09:30:00,09:30:00,D,4",
+		"early,08:00:00,08:00:00,A,1",
+		"early,08:05:00,08:05:00,B,2",
+		"early,08:20:00,08:20:00,C,3",
+		"early,08:30:00,08:30:00,D,4",
+		"back,10:00:00,10:00:00,D,1",
+		"back,10:30:00,10:30:00,A,2",
+		"broken,11:00:00,11:00:00,A,1",
+		"broken,10:50:00,10:50:00,B,2",
+		"short,12:00:00,12:00:00,A,1",
+	},
+}
+
+func TestRead(t *testing.T) {
+	feed, warnings := readFeed(t, testFeed)
+
+	t.Run("stations", func(t *testing.T) {
+		if len(feed.Stations) != 4 {
+			t.Fatalf("got %d stations, want 4", len(feed.Stations))
+		}
+		want := []model.Wheelchair{
+			model.WheelchairAccessible, model.WheelchairNotAccessible, model.WheelchairUnknown, model.WheelchairAccessible,
+		}
+		for i, st := range feed.Stations {
+			if st.Wheelchair != want[i] {
+				t.Errorf("%s wheelchair %v, want %v", st.Name, st.Wheelchair, want[i])
+			}
+		}
+		if !hasWarning(warnings, "stop X has no valid position") {
+			t.Errorf("no warning for stop X in %q", warnings)
+		}
+	})
+
+	t.Run("calendars", func(t *testing.T) {
+		if len(feed.Calendars) != 2 {
+			t.Fatalf("got %d calendars, want 2", len(feed.Calendars))
+		}
+		wk, hol := feed.Calendars[0], feed.Calendars[1]
+		if !wk.Monday || wk.Saturday || wk.StartDate != "2025-01-01" || wk.EndDate != "2025-12-31" {
+			t.Errorf("calendar WK %+v", wk)
+		}
+		if len(wk.Exceptions) != 1 || wk.Exceptions[0].Added || wk.Exceptions[0].Date != "2025-05-01" {
+			t.Errorf("calendar WK exceptions %+v", wk.Exceptions)
+		}
+		if hol.Name != "HOL" || hol.Monday || len(hol.Exceptions) != 1 || !hol.Exceptions[0].Added {
+			t.Errorf("calendar HOL %+v", hol)
+		}
+	})
+
+	t.Run("routes split by stop pattern", func(t *testing.T) {
+		if len(feed.Routes) != 2 {
+			t.Fatalf("got %d routes, want 2", len(feed.Routes))
+		}
+		forward, backward := feed.Routes[0], feed.Routes[1]
+		if forward.Name != "1 (Central A - Dock)" || backward.Name != "1 (Dock - Central A)" {
+			t.Errorf("route names %q and %q", forward.Name, backward.Name)
+		}
+		if len(forward.Trips) != 2 || len(backward.Trips) != 1 || backward.Trips[0].Calendar != 1 {
+			t.Fatalf("trips %+v and %+v", forward.Trips, backward.Trips)
+		}
+		// trips are ordered by departure, the interpolated one second
+		if got := forward.Trips[0].Times; joinInts(got) != joinInts([]int{8 * 3600, 8*3600 + 300, 8*3600 + 1200, 8*3600 + 1800}) {
+			t.Errorf("early trip times %v", got)
+		}
+		if got := forward.Trips[1].Times; joinInts(got) != joinInts([]int{9 * 3600, 9*3600 + 600, 9*3600 + 1200, 9*3600 + 1800}) {
+			t.Errorf("interpolated times %v", got)
+		}
+		if forward.Trips[1].Wheelchair != model.WheelchairAccessible {
+			t.Errorf("late trip wheelchair %v", forward.Trips[1].Wheelchair)
+		}
+	})
+
+	t.Run("skipped trips", func(t *testing.T) {
+		for _, w := range []string{
+			"trip broken goes back in time",
+			"trip short has less than two stops",
+			"trip lost has unknown service NONE",
+		} {
+			if !hasWarning(warnings, w) {
+				t.Errorf("no warning %q in %q", w, warnings)
+			}
+		}
+	})
+}
+
+func TestReadCalendarRequired(t *testing.T) {
+	files := make(map[string][]string)
+	for name, lines := range testFeed {
+		if name != calendarFile && name != calendarDatesFile {
+			files[name] = lines
+		}
+	}
+	r := feedZip(t, files)
+	if _, _, err := Read(r, r.Size()); err == nil {
+		t.Error("feed without calendars accepted")
+	}
+}
+
+func TestTripTimes(t *testing.T) {
+	tests := []struct {
+		name   string
+		stops  []stopTime
+		times  []int
+		reason string
+	}{
+		{
+			name:  "ordered by sequence",
+			stops: []stopTime{{seq: 5, station: 2, time: 200}, {seq: 1, station: 1, time: 100}},
+			times: []int{100, 200},
+		},
+		{
+			name: "two missing times",
+			stops: []stopTime{
+				{seq: 1, station: 1, time: 0}, {seq: 2, station: 2, time: -1},
+				{seq: 3, station: 3, time: -1}, {seq: 4, station: 4, time: 900},
+			},
+			times: []int{0, 300, 600, 900},
+		},
+		{
+			name:   "no time at the last stop",
+			stops:  []stopTime{{seq: 1, station: 1, time: 0}, {seq: 2, station: 2, time: -1}},
+			reason: "has no time at the first or last stop",
+		},
+		{
+			name:   "stop visited twice",
+			stops:  []stopTime{{seq: 1, station: 1, time: 0}, {seq: 2, station: 2, time: 60}, {seq: 3, station: 1, time: 120}},
+			reason: "visits a stop twice",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			times, _, reason := tripTimes(tc.stops)
+			if reason != tc.reason || joinInts(times) != joinInts(tc.times) {
+				t.Errorf("times %v %q, want %v %q", times, reason, tc.times, tc.reason)
+			}
+		})
+	}
+}
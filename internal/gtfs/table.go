@@ -0,0 +1,83 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var errNoFile = errors.New("file not found")
+
+// record is one row of a GTFS table, values are looked up by column name.
+type record struct {
+	cols   map[string]int
+	values []string
+}
+
+func (r record) get(name string) string {
+	i, ok := r.cols[name]
+	if !ok || i >= len(r.values) {
+		return ""
+	}
+	return strings.TrimSpace(r.values[i])
+}
+
+// zipFiles indexes the archive by file name, feeds zipped together with
+// their folder are read the same way.
+func zipFiles(zr *zip.Reader) map[string]*zip.File {
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[path.Base(f.Name)] = f
+	}
+	return files
+}
+
+// readTable calls fn for every row of the file, line is the line number in
+// the file for warnings. The required columns must be in the header.
+func readTable(files map[string]*zip.File, name string, required []string, fn func(line int, rec record) error) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%s: %w", name, errNoFile)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	defer rc.Close()
+
+	cr := csv.NewReader(rc)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, col := range header {
+		col = strings.TrimSpace(strings.TrimPrefix(col, "\ufeff"))
+		cols[col] = i
+	}
+	for _, col := range required {
+		if _, ok := cols[col]; !ok {
+			return fmt.Errorf("%s: column %s is required", name, col)
+		}
+	}
+
+	for {
+		values, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		line, _ := cr.FieldPos(0)
+		if err = fn(line, record{cols: cols, values: values}); err != nil {
+			return fmt.Errorf("%s line %d: %w", name, line, err)
+		}
+	}
+}
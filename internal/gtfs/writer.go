@@ -0,0 +1,238 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/config"
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+const (
+	agencyFile       = "agency.txt"
+	feedInfoFile     = "feed_info.txt"
+	translationsFile = "translations.txt"
+
+	// dailyService is the service_id of trips without a calendar, database
+	// ids never clash with it
+	dailyService = "daily"
+	busRouteType = "3"
+)
+
+// Timetable is the stored network to export, database ids become the GTFS
+// ids so they stay the same between exports.
+type Timetable struct {
+	Stations  []*model.Station
+	Routes    []*model.Route
+	Calendars []*model.Calendar
+	// StopTimes are ordered by trip and position
+	StopTimes []model.StopTime
+	// Start fills the dates of calendars without them, they then run for a
+	// year from Start
+	Start time.Time
+}
+
+type fileWriter struct {
+	zw  *zip.Writer
+	err error
+}
+
+// write adds one CSV file, rows is called with a function adding a row.
+func (fw *fileWriter) write(name string, header []string, rows func(add func(...string))) {
+	if fw.err != nil {
+		return
+	}
+	f, err := fw.zw.Create(name)
+	if err != nil {
+		fw.err = err
+		return
+	}
+	cw := csv.NewWriter(f)
+	cw.Write(header)
+	rows(func(values ...string) {
+		cw.Write(values)
+	})
+	cw.Flush()
+	fw.err = cw.Error()
+}
+
+// Write streams the timetable as a GTFS zip. Stations without a position
+// are left out together with their stop times, GTFS requires one.
+func Write(w io.Writer, agency config.GTFS, t Timetable) error {
+	zw := zip.NewWriter(w)
+	fw := &fileWriter{zw: zw}
+
+	fw.write(agencyFile, []string{"agency_name", "agency_url", "agency_timezone", "agency_lang"}, func(add func(...string)) {
+		add(agency.AgencyName, agency.AgencyURL, agency.Timezone, agency.Lang)
+	})
+	fw.write(feedInfoFile, []string{"feed_publisher_name", "feed_publisher_url", "feed_lang"}, func(add func(...string)) {
+		add(agency.AgencyName, agency.AgencyURL, agency.Lang)
+	})
+
+	placed := make(map[int]bool, len(t.Stations))
+	header := []string{"stop_id", "stop_name", "stop_lat", "stop_lon", "zone_id", "wheelchair_boarding"}
+	fw.write(stopsFile, header, func(add func(...string)) {
+		for _, st := range t.Stations {
+			if st.Lat == nil || st.Lon == nil {
+				continue
+			}
+			placed[st.Id] = true
+			zone := ""
+			if st.ZoneId != nil {
+				zone = strconv.Itoa(*st.ZoneId)
+			}
+			add(strconv.Itoa(st.Id), st.Name, formatFloat(*st.Lat), formatFloat(*st.Lon), zone,
+				strconv.Itoa(int(st.Wheelchair)))
+		}
+	})
+
+	fw.write(routesFile, []string{"route_id", "route_short_name", "route_type"}, func(add func(...string)) {
+		for _, r := range t.Routes {
+			add(strconv.Itoa(r.Id), r.Name, busRouteType)
+		}
+	})
+
+	trips := make(map[int][]model.StopTime)
+	var tripIds []int
+	for _, st := range t.StopTimes {
+		if !placed[st.StationId] {
+			continue
+		}
+		if _, ok := trips[st.TripId]; !ok {
+			tripIds = append(tripIds, st.TripId)
+		}
+		trips[st.TripId] = append(trips[st.TripId], st)
+	}
+	daily := false
+	header = []string{"route_id", "service_id", "trip_id", "trip_headsign", "wheelchair_accessible"}
+	fw.write(tripsFile, header, func(add func(...string)) {
+		for _, id := range tripIds {
+			stops := trips[id]
+			if len(stops) < 2 {
+				continue
+			}
+			service := dailyService
+			if stops[0].CalendarId != 0 {
+				service = strconv.Itoa(stops[0].CalendarId)
+			} else {
+				daily = true
+			}
+			add(strconv.Itoa(stops[0].RouteId), service, strconv.Itoa(id), stops[0].Headsign,
+				strconv.Itoa(int(stops[0].Wheelchair)))
+		}
+	})
+
+	header = []string{"trip_id", "arrival_time", "departure_time", "stop_id", "stop_sequence"}
+	fw.write(stopTimesFile, header, func(add func(...string)) {
+		for _, id := range tripIds {
+			stops := trips[id]
+			if len(stops) < 2 {
+				continue
+			}
+			for _, st := range stops {
+				clock := model.FormatClock(st.Time)
+				add(strconv.Itoa(id), clock, clock, strconv.Itoa(st.StationId), strconv.Itoa(st.Pos))
+			}
+		}
+	})
+
+	writeCalendars(fw, t, daily)
+	writeTranslations(fw, t, placed)
+
+	if fw.err != nil {
+		return fw.err
+	}
+	return zw.Close()
+}
+
+func writeCalendars(fw *fileWriter, t Timetable, daily bool) {
+	header := []string{"service_id", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday",
+		"start_date", "end_date"}
+	fw.write(calendarFile, header, func(add func(...string)) {
+		if daily {
+			start, end := serviceDates(t.Start, "", "")
+			add(dailyService, "1", "1", "1", "1", "1", "1", "1", start, end)
+		}
+		for _, c := range t.Calendars {
+			start, end := serviceDates(t.Start, c.StartDate, c.EndDate)
+			add(strconv.Itoa(c.Id), flag(c.Monday), flag(c.Tuesday), flag(c.Wednesday), flag(c.Thursday),
+				flag(c.Friday), flag(c.Saturday), flag(c.Sunday), start, end)
+		}
+	})
+
+	fw.write(calendarDatesFile, []string{"service_id", "date", "exception_type"}, func(add func(...string)) {
+		for _, c := range t.Calendars {
+			for _, e := range c.Exceptions {
+				d, err := time.Parse(model.DateLayout, e.Date)
+				if err != nil {
+					continue
+				}
+				exception := "2"
+				if e.Added {
+					exception = "1"
+				}
+				add(strconv.Itoa(c.Id), d.Format(dateLayout), exception)
+			}
+		}
+	})
+}
+
+func writeTranslations(fw *fileWriter, t Timetable, placed map[int]bool) {
+	header := []string{"table_name", "field_name", "language", "translation", "record_id"}
+	fw.write(translationsFile, header, func(add func(...string)) {
+		for _, st := range t.Stations {
+			if !placed[st.Id] {
+				continue
+			}
+			for _, lang := range sortedLangs(st.Translations) {
+				add("stops", "stop_name", lang, st.Translations[lang], strconv.Itoa(st.Id))
+			}
+		}
+		for _, r := range t.Routes {
+			for _, lang := range sortedLangs(r.Translations) {
+				add("routes", "route_short_name", lang, r.Translations[lang], strconv.Itoa(r.Id))
+			}
+		}
+	})
+}
+
+// serviceDates converts the calendar dates into GTFS ones, a missing start
+// is the export start and a missing end is a year after the start.
+func serviceDates(from time.Time, start, end string) (string, string) {
+	s, err := time.Parse(model.DateLayout, start)
+	if err != nil {
+		s = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	e, err := time.Parse(model.DateLayout, end)
+	if err != nil {
+		e = s.AddDate(1, 0, -1)
+	}
+	if s.After(e) {
+		s = e
+	}
+	return s.Format(dateLayout), e.Format(dateLayout)
+}
+
+func sortedLangs(t model.Translations) []string {
+	langs := make([]string, 0, len(t))
+	for lang := range t {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+func flag(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
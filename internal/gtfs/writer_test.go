@@ -0,0 +1,119 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/config"
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+func TestWriteReadBack(t *testing.T) {
+	lat, lon := 59.9, 30.3
+	station := func(id int, name string) *model.Station {
+		return &model.Station{Id: id, Name: name, Lat: &lat, Lon: &lon, Wheelchair: model.WheelchairAccessible}
+	}
+	stop := func(tripId, calendarId, stationId, pos int, clock string) model.StopTime {
+		sec, err := model.ParseClock(clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return model.StopTime{RouteId: 5, TripId: tripId, CalendarId: calendarId, StationId: stationId, Pos: pos, Time: sec}
+	}
+	tt := Timetable{
+		Stations: []*model.Station{
+			station(10, "Central"), station(20, "Bay"), station(30, "Dock"),
+			{Id: 40, Name: "Nowhere"},
+		},
+		Routes: []*model.Route{{Id: 5, Name: "5"}},
+		Calendars: []*model.Calendar{{
+			Id: 7, Name: "Weekdays", Monday: true, Friday: true, StartDate: "2025-01-01", EndDate: "2025-12-31",
+			Exceptions: []model.CalendarDate{{Date: "2025-05-01"}},
+		}},
+		StopTimes: []model.StopTime{
+			stop(100, 7, 10, 0, "08:00"),
+			stop(100, 7, 20, 1, "08:10"),
+			stop(100, 7, 30, 2, "08:25"),
+			stop(101, 0, 10, 0, "23:50"),
+			stop(101, 0, 40, 1, "24:00"),
+			stop(101, 0, 30, 3, "24:20"),
+		},
+		Start: time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC),
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, config.GTFS{AgencyName: "Bus", Timezone: "Europe/Moscow"}, tt); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := func(name string, cols ...string) []string {
+		var out []string
+		err := readTable(zipFiles(zr), name, cols, func(line int, rec record) error {
+			row := ""
+			for _, col := range cols {
+				row += rec.get(col) + ";"
+			}
+			out = append(out, row)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	equal := func(name string, got, want []string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("%s rows %q, want %q", name, got, want)
+			return
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%s rows %q, want %q", name, got, want)
+				return
+			}
+		}
+	}
+
+	equal(stopsFile, rows(stopsFile, "stop_id"), []string{"10;", "20;", "30;"})
+	equal(tripsFile, rows(tripsFile, "trip_id", "service_id"), []string{"100;7;", "101;daily;"})
+	equal(stopTimesFile, rows(stopTimesFile, "trip_id", "stop_id", "stop_sequence", "departure_time"), []string{
+		"100;10;0;08:00:00;", "100;20;1;08:10:00;", "100;30;2;08:25:00;",
+		"101;10;0;23:50:00;", "101;30;3;24:20:00;",
+	})
+	equal(calendarFile, rows(calendarFile, "service_id", "monday", "saturday", "start_date", "end_date"), []string{
+		"daily;1;1;20250304;20260303;", "7;1;0;20250101;20251231;",
+	})
+
+	feed, warnings, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("warnings %q", warnings)
+	}
+	if len(feed.Stations) != 3 || len(feed.Calendars) != 2 || len(feed.Routes) != 2 {
+		t.Fatalf("read back %d stations, %d calendars, %d routes", len(feed.Stations), len(feed.Calendars), len(feed.Routes))
+	}
+	daily := feed.Calendars[0]
+	if daily.Name != dailyService || !daily.Sunday {
+		t.Errorf("daily calendar %+v", daily)
+	}
+	weekdays := feed.Calendars[1]
+	if weekdays.Saturday || len(weekdays.Exceptions) != 1 || weekdays.Exceptions[0].Date != "2025-05-01" {
+		t.Errorf("weekday calendar %+v", weekdays)
+	}
+	for _, r := range feed.Routes {
+		if len(r.Trips) != 1 {
+			t.Fatalf("route %s has %d trips", r.Name, len(r.Trips))
+		}
+		if last := r.Trips[0].Times[len(r.Trips[0].Times)-1]; last != 8*3600+1500 && last != 24*3600+1200 {
+			t.Errorf("route %s ends at %d", r.Name, last)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/alexeybs90/go_bus_routes/pkg/logger"
+)
+
+// attachment sends the download headers right before the first byte, so
+// errors found while loading the data still get a JSON response.
+type attachment struct {
+	http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (a *attachment) Write(p []byte) (int, error) {
+	if !a.started {
+		a.started = true
+		a.Header().Set("Content-Type", a.contentType)
+		a.Header().Set("Content-Disposition", `attachment; filename="`+a.filename+`"`)
+		a.WriteHeader(http.StatusOK)
+	}
+	return a.ResponseWriter.Write(p)
+}
+
+// attachmentError can only log errors once the download has started.
+func (h *handlers) attachmentError(log logger.Logger, err error, a *attachment) {
+	if a.started {
+		log.Error(err.Error())
+		return
+	}
+	h.doServerError(log, err, a.ResponseWriter)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const csvFormat = "csv"
+
+type responseCSVReport struct {
+	response
+	Item model.CSVReport `json:"item"`
+}
+
+// ExportCSV serves /api/stations.csv and /api/routes.csv, the sep param
+// sets the separator for spreadsheets expecting ";".
+func (h *handlers) ExportCSV(w http.ResponseWriter, r *http.Request, entity string) {
+	log := h.logger.With(
+		slog.String("api", "handlers.ExportCSV"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	sep, err := separatorParam(r, "sep")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	out := &attachment{ResponseWriter: w, contentType: "text/csv; charset=UTF-8", filename: entity + "s.csv"}
+	switch entity {
+	case stationEntity:
+		err = h.service.ExportStationsCSV(r.Context(), out, sep)
+	case routeEntity:
+		err = h.service.ExportRoutesCSV(r.Context(), out, sep)
+	default:
+		err = errors.New("wrong entity error")
+	}
+	if err != nil {
+		h.attachmentError(log, err, out)
+		return
+	}
+
+	log.Info("done ok!")
+}
+
+// ImportCSV takes the file as the request body or as the "file" field of a
+// multipart form, the rows that fail are listed in the report.
+func (h *handlers) ImportCSV(w http.ResponseWriter, r *http.Request, entity string) {
+	log := h.logger.With(
+		slog.String("api", "handlers.ImportCSV"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	body, err := uploadBody(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	defer body.Close()
+
+	var report model.CSVReport
+	switch entity {
+	case stationEntity:
+		report, err = h.service.ImportStationsCSV(r.Context(), body)
+	case routeEntity:
+		report, err = h.service.ImportRoutesCSV(r.Context(), body)
+	default:
+		err = errors.New("wrong entity error")
+	}
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseCSVReport{
+		response: response{Status: StatusOK},
+		Item:     report,
+	})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type responseFare struct {
+	response
+	Item model.Fare `json:"item"`
+}
+
+type responseZonePrices struct {
+	response
+	Items []model.ZonePrice `json:"items"`
+}
+
+func (h *handlers) GetFare(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.GetFare"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	fromId, err := strconv.Atoi(r.URL.Query().Get("from_id"))
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	toId, err := strconv.Atoi(r.URL.Query().Get("to_id"))
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	routeId := 0
+	if v := r.URL.Query().Get("route_id"); v != "" {
+		routeId, err = strconv.Atoi(v)
+		if err != nil {
+			h.doServerError(log, err, w)
+			return
+		}
+	}
+
+	item, err := h.service.GetFare(r.Context(), fromId, toId, routeId)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseFare{
+		response: response{Status: StatusOK},
+		Item:     item,
+	})
+}
+
+func (h *handlers) GetZonePrices(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.GetZonePrices"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	items, err := h.repository.GetZonePrices(r.Context())
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseZonePrices{
+		response: response{Status: StatusOK},
+		Items:    items,
+	})
+}
+
+func (h *handlers) SaveZonePrice(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.SaveZonePrice"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	var item model.ZonePrice
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r.Body); err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	if err := json.Unmarshal(buf.Bytes(), &item); err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	if err := h.repository.SaveZonePrice(r.Context(), item); err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	h.responseOK(w)
+}
+
+func (h *handlers) DeleteZonePrice(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.DeleteZonePrice"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	fromZoneId, err := strconv.Atoi(r.URL.Query().Get("from_zone_id"))
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	toZoneId, err := strconv.Atoi(r.URL.Query().Get("to_zone_id"))
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	if err = h.repository.DeleteZonePrice(r.Context(), fromZoneId, toZoneId); err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	h.responseOK(w)
+}
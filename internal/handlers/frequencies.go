@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type responseFrequency struct {
+	response
+	Item model.Frequency `json:"item"`
+}
+
+type responseFrequencies struct {
+	response
+	Items []model.Frequency `json:"items"`
+}
+
+func (h *handlers) GetFrequencies(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.GetFrequencies"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	routeId, err := urlParamInt(r, "id")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	items, err := h.repository.GetFrequencies(r.Context(), routeId)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseFrequencies{
+		response: response{Status: StatusOK},
+		Items:    items,
+	})
+}
+
+func (h *handlers) CreateFrequency(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.CreateFrequency"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	item, err := h.readFrequency(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	item.Id = 0
+
+	err = h.repository.CreateFrequency(r.Context(), &item)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(responseFrequency{
+		response: response{Status: StatusOK},
+		Item:     item,
+	})
+}
+
+func (h *handlers) UpdateFrequency(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.UpdateFrequency"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	item, err := h.readFrequency(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	err = h.repository.UpdateFrequency(r.Context(), &item)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	h.responseOK(w)
+}
+
+func (h *handlers) DeleteFrequency(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.DeleteFrequency"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	routeId, err := urlParamInt(r, "id")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	frequencyId, err := urlParamInt(r, "frequencyId")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	err = h.repository.DeleteFrequency(r.Context(), routeId, frequencyId)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	h.responseOK(w)
+}
+
+func (h *handlers) readFrequency(r *http.Request) (model.Frequency, error) {
+	var item model.Frequency
+
+	routeId, err := urlParamInt(r, "id")
+	if err != nil {
+		return item, err
+	}
+
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(r.Body); err != nil {
+		return item, err
+	}
+	if err = json.Unmarshal(buf.Bytes(), &item); err != nil {
+		return item, err
+	}
+	item.RouteId = routeId
+
+	if item.Headway <= 0 {
+		return item, errors.New("headway must be a positive number of minutes")
+	}
+	start, err := model.ParseClock(item.StartTime)
+	if err != nil {
+		return item, err
+	}
+	end, err := model.ParseClock(item.EndTime)
+	if err != nil {
+		return item, err
+	}
+	if end <= start {
+		return item, errors.New("end_time must be after start_time")
+	}
+	item.StartTime = model.FormatClock(start)
+	item.EndTime = model.FormatClock(end)
+	return item, nil
+}
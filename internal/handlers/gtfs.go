@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// maxGTFSSize caps the uploaded zip, the whole feed is held in memory
+const maxGTFSSize = 256 << 20
+
+type responseImport struct {
+	response
+	Item model.ImportReport `json:"item"`
+}
+
+// ImportGTFS takes the zip as the request body or as the "file" field of a
+// multipart form.
+func (h *handlers) ImportGTFS(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.ImportGTFS"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	var opts model.ImportOptions
+	var err error
+	if opts.DryRun, err = boolParam(r, "dry_run"); err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	if opts.Replace, err = boolParam(r, "replace"); err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxGTFSSize)
+	body, err := uploadBody(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	defer body.Close()
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(body); err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	report, err := h.service.ImportGTFS(r.Context(), bytes.NewReader(buf.Bytes()), int64(buf.Len()), opts)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseImport{
+		response: response{Status: StatusOK},
+		Item:     report,
+	})
+}
+
+func (h *handlers) ExportGTFS(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.ExportGTFS"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	out := &attachment{ResponseWriter: w, contentType: "application/zip", filename: "gtfs.zip"}
+	if err := h.service.ExportGTFS(r.Context(), out); err != nil {
+		h.attachmentError(log, err, out)
+		return
+	}
+
+	log.Info("done ok!")
+}
+
+func (h *handlers) ExportNeTEx(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.ExportNeTEx"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	out := &attachment{ResponseWriter: w, contentType: "application/xml", filename: "netex.xml"}
+	if err := h.service.ExportNeTEx(r.Context(), out); err != nil {
+		h.attachmentError(log, err, out)
+		return
+	}
+
+	log.Info("done ok!")
+}
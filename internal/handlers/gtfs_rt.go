@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/alexeybs90/go_bus_routes/internal/realtime"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const protobufContentType = "application/x-protobuf"
+
+func (h *handlers) GetTripUpdatesFeed(w http.ResponseWriter, r *http.Request) {
+	h.realtimeFeed(w, r, "handlers.GetTripUpdatesFeed", h.service.TripUpdatesFeed)
+}
+
+func (h *handlers) GetAlertsFeed(w http.ResponseWriter, r *http.Request) {
+	h.realtimeFeed(w, r, "handlers.GetAlertsFeed", h.service.AlertsFeed)
+}
+
+// realtimeFeed writes the feed as protobuf, the .json extension gives the
+// same message as JSON for debugging.
+func (h *handlers) realtimeFeed(w http.ResponseWriter, r *http.Request, api string,
+	build func(ctx context.Context) (*realtime.FeedMessage, error)) {
+	log := h.logger.With(
+		slog.String("api", api),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	msg, err := build(r.Context())
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	if urlFormat(r) == "json" {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(msg)
+		return
+	}
+	w.Header().Set("Content-Type", protobufContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(realtime.Encode(msg))
+}
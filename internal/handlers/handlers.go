@@ -3,30 +3,54 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/alexeybs90/go_bus_routes/internal/realtime"
 	"github.com/alexeybs90/go_bus_routes/pkg/logger"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 const (
-	contentType   = "application/json; charset=UTF-8"
-	routeEntity   = "route"
-	stationEntity = "station"
+	contentType    = "application/json; charset=UTF-8"
+	routeEntity    = "route"
+	stationEntity  = "station"
+	calendarEntity = "calendar"
+	fareZoneEntity = "fare_zone"
 )
 
 type Service interface {
-	FindBus(ctx context.Context, fromId int, toId int) ([]model.Model, error)
+	FindBus(ctx context.Context, q model.JourneyQuery) ([]model.Journey, error)
+	GetDepartures(ctx context.Context, stationId int, date time.Time, from int, limit int) ([]model.Departure, error)
+	GetReachable(ctx context.Context, fromId int, date time.Time, departAt int, budget int) ([]model.Reachable, error)
+	GenerateTrips(ctx context.Context, routeId int, pattern model.TripPattern) ([]model.Trip, error)
+	SuggestTransfers(ctx context.Context, radius float64) ([]model.Transfer, error)
+	GetFare(ctx context.Context, fromId int, toId int, routeId int) (model.Fare, error)
+	SearchStations(ctx context.Context, query string, limit int) ([]model.StationMatch, error)
+	ImportGTFS(ctx context.Context, r io.ReaderAt, size int64, opts model.ImportOptions) (model.ImportReport, error)
+	ExportGTFS(ctx context.Context, w io.Writer) error
+	ExportNeTEx(ctx context.Context, w io.Writer) error
+	GetVehicles(ctx context.Context) ([]model.VehiclePosition, error)
+	TripUpdatesFeed(ctx context.Context) (*realtime.FeedMessage, error)
+	AlertsFeed(ctx context.Context) (*realtime.FeedMessage, error)
+	ExportStationsCSV(ctx context.Context, w io.Writer, sep rune) error
+	ExportRoutesCSV(ctx context.Context, w io.Writer, sep rune) error
+	ImportStationsCSV(ctx context.Context, r io.Reader) (model.CSVReport, error)
+	ImportRoutesCSV(ctx context.Context, r io.Reader) (model.CSVReport, error)
 }
 
 type handlers struct {
 	repository model.Repository
 	logger     logger.Logger
 	service    Service
+	// location is the agency timezone the service days are counted in
+	location *time.Location
 }
 
 type response struct {
@@ -44,22 +68,32 @@ type responseItems struct {
 	Items []model.Model `json:"items,omitempty"`
 }
 
+type responseJourneys struct {
+	response
+	Items []model.Journey `json:"items"`
+}
+
 const (
 	StatusOK    = "OK"
 	StatusError = "Error"
 )
 
-func NewHandler(repository model.Repository, logger logger.Logger, service Service) *handlers {
+func NewHandler(repository model.Repository, logger logger.Logger, service Service, location *time.Location) *handlers {
 	return &handlers{
 		repository: repository,
 		logger:     logger,
 		service:    service,
+		location:   location,
 	}
 }
 
 func (h *handlers) Register(router *chi.Mux) {
 	router.Get("/api/stations", h.GetStations)
+	router.Get("/api/stations/nearby", h.GetNearbyStations)
+	router.Get("/api/stations/search", h.SearchStations)
 	router.Get("/api/stations/{id}", h.GetStation)
+	router.Get("/api/stations/{id}/departures", h.GetDepartures)
+	router.Get("/api/stations/{id}/reachable", h.GetReachable)
 	router.Post("/api/stations", h.CreateStation)
 	router.Put("/api/stations", h.UpdateStation)
 	router.Delete("/api/stations/{id}", h.DeleteStation)
@@ -70,6 +104,51 @@ func (h *handlers) Register(router *chi.Mux) {
 	router.Put("/api/routes", h.UpdateRoute)
 	router.Delete("/api/routes/{id}", h.DeleteRoute)
 
+	router.Get("/api/routes/{id}/shape", h.GetShape)
+	router.Put("/api/routes/{id}/shape", h.UpdateShape)
+
+	router.Get("/api/routes/{id}/trips", h.GetTrips)
+	router.Get("/api/routes/{id}/trips/{tripId}", h.GetTrip)
+	router.Post("/api/routes/{id}/trips", h.CreateTrip)
+	router.Post("/api/routes/{id}/trips/generate", h.GenerateTrips)
+	router.Put("/api/routes/{id}/trips", h.UpdateTrip)
+	router.Delete("/api/routes/{id}/trips/{tripId}", h.DeleteTrip)
+
+	router.Get("/api/routes/{id}/frequencies", h.GetFrequencies)
+	router.Post("/api/routes/{id}/frequencies", h.CreateFrequency)
+	router.Put("/api/routes/{id}/frequencies", h.UpdateFrequency)
+	router.Delete("/api/routes/{id}/frequencies/{frequencyId}", h.DeleteFrequency)
+
+	router.Get("/api/calendars", h.GetCalendars)
+	router.Get("/api/calendars/{id}", h.GetCalendar)
+	router.Post("/api/calendars", h.CreateCalendar)
+	router.Put("/api/calendars", h.UpdateCalendar)
+	router.Delete("/api/calendars/{id}", h.DeleteCalendar)
+
+	router.Get("/api/transfers", h.GetTransfers)
+	router.Get("/api/transfers/suggest", h.SuggestTransfers)
+	router.Post("/api/transfers", h.CreateTransfer)
+	router.Put("/api/transfers", h.UpdateTransfer)
+	router.Delete("/api/transfers/{id}", h.DeleteTransfer)
+
+	router.Get("/api/fare-zones", h.GetFareZones)
+	router.Get("/api/fare-zones/prices", h.GetZonePrices)
+	router.Put("/api/fare-zones/prices", h.SaveZonePrice)
+	router.Delete("/api/fare-zones/prices", h.DeleteZonePrice)
+	router.Get("/api/fare-zones/{id}", h.GetFareZone)
+	router.Post("/api/fare-zones", h.CreateFareZone)
+	router.Put("/api/fare-zones", h.UpdateFareZone)
+	router.Delete("/api/fare-zones/{id}", h.DeleteFareZone)
+
+	router.Post("/api/import/gtfs", h.ImportGTFS)
+	router.Get("/api/export/gtfs", h.ExportGTFS)
+	router.Get("/api/export/netex", h.ExportNeTEx)
+
+	router.Get("/api/vehicles", h.GetVehicles)
+	router.Get("/api/gtfs-rt/trip-updates", h.GetTripUpdatesFeed)
+	router.Get("/api/gtfs-rt/alerts", h.GetAlertsFeed)
+
+	router.Get("/api/fare", h.GetFare)
 	router.Get("/api/find-bus", h.FindBus)
 }
 
@@ -99,32 +178,113 @@ func (h *handlers) FindBus(w http.ResponseWriter, r *http.Request) {
 	)
 	w.Header().Set("Content-Type", contentType)
 
-	fromId, _ := strconv.Atoi(r.URL.Query().Get("from_id"))
-	toId, _ := strconv.Atoi(r.URL.Query().Get("to_id"))
-	items, err := h.service.FindBus(r.Context(), fromId, toId)
+	query := model.JourneyQuery{MaxTransfers: -1}
+	query.FromId, _ = strconv.Atoi(r.URL.Query().Get("from_id"))
+	query.ToId, _ = strconv.Atoi(r.URL.Query().Get("to_id"))
+	if v := r.URL.Query().Get("max_transfers"); v != "" {
+		maxTransfers, err := strconv.Atoi(v)
+		if err != nil {
+			h.doServerError(log, err, w)
+			return
+		}
+		query.MaxTransfers = maxTransfers
+	}
+	if v := r.URL.Query().Get("depart_at"); v != "" {
+		departAt, err := model.ParseClock(v)
+		if err != nil {
+			h.doServerError(log, err, w)
+			return
+		}
+		query.DepartAt = &departAt
+	}
+	if v := r.URL.Query().Get("arrive_by"); v != "" {
+		if query.DepartAt != nil {
+			h.doServerError(log, errors.New("depart_at and arrive_by can not be used together"), w)
+			return
+		}
+		arriveBy, err := model.ParseClock(v)
+		if err != nil {
+			h.doServerError(log, err, w)
+			return
+		}
+		query.ArriveBy = &arriveBy
+	}
+	if query.DepartAt != nil || query.ArriveBy != nil {
+		date, err := dateParam(r, "date", h.location)
+		if err != nil {
+			h.doServerError(log, err, w)
+			return
+		}
+		query.Date = date
+	}
+	for _, v := range r.URL.Query()["via_id"] {
+		viaId, err := strconv.Atoi(v)
+		if err != nil {
+			h.doServerError(log, err, w)
+			return
+		}
+		query.ViaIds = append(query.ViaIds, viaId)
+	}
+	for _, v := range r.URL.Query()["avoid_id"] {
+		avoidId, err := strconv.Atoi(v)
+		if err != nil {
+			h.doServerError(log, err, w)
+			return
+		}
+		query.AvoidIds = append(query.AvoidIds, avoidId)
+	}
+	wheelchair, err := boolParam(r, "wheelchair")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	query.Wheelchair = wheelchair
+	items, err := h.service.FindBus(r.Context(), query)
 
 	if err != nil {
 		h.doServerError(log, err, w)
 		return
 	}
+	loc, err := h.newLocalizer(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	loc.journeys(items)
 
 	log.Info("done ok!")
 	resp := json.NewEncoder(w)
 	w.WriteHeader(http.StatusOK)
-	resp.Encode(responseItems{
+	resp.Encode(responseJourneys{
 		response: response{Status: StatusOK},
 		Items:    items,
 	})
 }
 
 func (h *handlers) GetRoutes(w http.ResponseWriter, r *http.Request) {
+	if urlFormat(r) == csvFormat {
+		h.ExportCSV(w, r, routeEntity)
+		return
+	}
 	h.GetList(w, r, routeEntity)
 }
 
 func (h *handlers) GetStations(w http.ResponseWriter, r *http.Request) {
+	if urlFormat(r) == csvFormat {
+		h.ExportCSV(w, r, stationEntity)
+		return
+	}
 	h.GetList(w, r, stationEntity)
 }
 
+func (h *handlers) GetCalendars(w http.ResponseWriter, r *http.Request) {
+	h.GetList(w, r, calendarEntity)
+}
+
+func (h *handlers) GetFareZones(w http.ResponseWriter, r *http.Request) {
+	h.GetList(w, r, fareZoneEntity)
+}
+
 func (h *handlers) GetRoute(w http.ResponseWriter, r *http.Request) {
 	h.GetOne(w, r, routeEntity)
 }
@@ -133,16 +293,42 @@ func (h *handlers) GetStation(w http.ResponseWriter, r *http.Request) {
 	h.GetOne(w, r, stationEntity)
 }
 
+func (h *handlers) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	h.GetOne(w, r, calendarEntity)
+}
+
+func (h *handlers) GetFareZone(w http.ResponseWriter, r *http.Request) {
+	h.GetOne(w, r, fareZoneEntity)
+}
+
 func (h *handlers) CreateRoute(w http.ResponseWriter, r *http.Request) {
+	if urlFormat(r) == csvFormat {
+		h.ImportCSV(w, r, routeEntity)
+		return
+	}
 	route := &model.Route{}
 	h.Create(w, r, route)
 }
 
 func (h *handlers) CreateStation(w http.ResponseWriter, r *http.Request) {
+	if urlFormat(r) == csvFormat {
+		h.ImportCSV(w, r, stationEntity)
+		return
+	}
 	station := &model.Station{}
 	h.Create(w, r, station)
 }
 
+func (h *handlers) CreateCalendar(w http.ResponseWriter, r *http.Request) {
+	item := &model.Calendar{}
+	h.Create(w, r, item)
+}
+
+func (h *handlers) CreateFareZone(w http.ResponseWriter, r *http.Request) {
+	item := &model.FareZone{}
+	h.Create(w, r, item)
+}
+
 func (h *handlers) UpdateRoute(w http.ResponseWriter, r *http.Request) {
 	item := &model.Route{}
 	h.Update(w, r, item)
@@ -153,6 +339,16 @@ func (h *handlers) UpdateStation(w http.ResponseWriter, r *http.Request) {
 	h.Update(w, r, item)
 }
 
+func (h *handlers) UpdateCalendar(w http.ResponseWriter, r *http.Request) {
+	item := &model.Calendar{}
+	h.Update(w, r, item)
+}
+
+func (h *handlers) UpdateFareZone(w http.ResponseWriter, r *http.Request) {
+	item := &model.FareZone{}
+	h.Update(w, r, item)
+}
+
 func (h *handlers) DeleteRoute(w http.ResponseWriter, r *http.Request) {
 	item := &model.Route{}
 	h.Delete(w, r, item)
@@ -162,3 +358,13 @@ func (h *handlers) DeleteStation(w http.ResponseWriter, r *http.Request) {
 	item := &model.Station{}
 	h.Delete(w, r, item)
 }
+
+func (h *handlers) DeleteCalendar(w http.ResponseWriter, r *http.Request) {
+	item := &model.Calendar{}
+	h.Delete(w, r, item)
+}
+
+func (h *handlers) DeleteFareZone(w http.ResponseWriter, r *http.Request) {
+	item := &model.FareZone{}
+	h.Delete(w, r, item)
+}
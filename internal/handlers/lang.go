@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// localizer swaps station and route names for their translations in the
+// languages the client asked for.
+type localizer struct {
+	langs    []string
+	stations map[int]model.Translations
+	routes   map[int]model.Translations
+}
+
+// requestLangs reads the lang query param, or the Accept-Language header
+// ordered by quality.
+func requestLangs(r *http.Request) []string {
+	if v := r.URL.Query().Get("lang"); v != "" {
+		return []string{strings.ToLower(v)}
+	}
+
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	var found []weighted
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		found = append(found, weighted{lang: strings.ToLower(lang), q: q})
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].q > found[j].q })
+
+	langs := make([]string, 0, len(found))
+	for _, w := range found {
+		langs = append(langs, w.lang)
+	}
+	return langs
+}
+
+// newLocalizer loads translations only when the request names a language.
+func (h *handlers) newLocalizer(r *http.Request) (*localizer, error) {
+	l := &localizer{langs: requestLangs(r)}
+	if len(l.langs) == 0 {
+		return l, nil
+	}
+
+	var err error
+	if l.stations, err = h.repository.GetTranslations(r.Context(), "station"); err != nil {
+		return nil, err
+	}
+	if l.routes, err = h.repository.GetTranslations(r.Context(), "route"); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *localizer) station(id int, name string) string {
+	return l.stations[id].Pick(l.langs, name)
+}
+
+func (l *localizer) route(id int, name string) string {
+	return l.routes[id].Pick(l.langs, name)
+}
+
+func (l *localizer) place(p *model.Place) {
+	p.Name = l.station(p.Id, p.Name)
+}
+
+func (l *localizer) models(items ...model.Model) {
+	if len(l.langs) == 0 {
+		return
+	}
+	for _, item := range items {
+		switch v := item.(type) {
+		case *model.Station:
+			v.Name = l.station(v.Id, v.Name)
+		case *model.Route:
+			v.Name = l.route(v.Id, v.Name)
+			for i := range v.Stations {
+				v.Stations[i].Name = l.station(v.Stations[i].Id, v.Stations[i].Name)
+			}
+		}
+	}
+}
+
+func (l *localizer) journeys(items []model.Journey) {
+	if len(l.langs) == 0 {
+		return
+	}
+	for _, j := range items {
+		for i := range j.Legs {
+			leg := &j.Legs[i]
+			if !leg.Walk {
+				leg.RouteName = l.route(leg.RouteId, leg.RouteName)
+			}
+			l.place(&leg.Board)
+			l.place(&leg.Alight)
+			if leg.Transfer != nil {
+				l.place(leg.Transfer)
+			}
+		}
+	}
+}
+
+func (l *localizer) departures(items []model.Departure) {
+	for i := range items {
+		items[i].RouteName = l.route(items[i].RouteId, items[i].RouteName)
+	}
+}
+
+func (l *localizer) reachable(items []model.Reachable) {
+	for i := range items {
+		l.place(&items[i].Station)
+	}
+}
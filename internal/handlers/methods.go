@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"log/slog"
@@ -66,6 +67,11 @@ func (h *handlers) Update(w http.ResponseWriter, r *http.Request, item model.Mod
 		h.doServerError(log, err, w)
 		return
 	}
+	item, err = h.merge(r.Context(), item, buf.Bytes())
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
 
 	err = h.repository.Update(r.Context(), item)
 	if err != nil {
@@ -135,8 +141,15 @@ func (h *handlers) GetOne(w http.ResponseWriter, r *http.Request, entity string)
 	switch entity {
 	case routeEntity:
 		item, err = h.repository.GetRoute(r.Context(), itemId)
+		if err == nil {
+			err = h.withShapes(r, []model.Model{item})
+		}
 	case stationEntity:
 		item, err = h.repository.GetStation(r.Context(), itemId)
+	case calendarEntity:
+		item, err = h.repository.GetCalendar(r.Context(), itemId)
+	case fareZoneEntity:
+		item, err = h.repository.GetFareZone(r.Context(), itemId)
 	default:
 		h.doServerError(log, errors.New("wrong entity error"), w)
 		return
@@ -145,6 +158,12 @@ func (h *handlers) GetOne(w http.ResponseWriter, r *http.Request, entity string)
 		h.doServerError(log, err, w)
 		return
 	}
+	loc, err := h.newLocalizer(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	loc.models(item)
 
 	log.Info("done ok!")
 	w.WriteHeader(http.StatusOK)
@@ -169,8 +188,15 @@ func (h *handlers) GetList(w http.ResponseWriter, r *http.Request, entity string
 	switch entity {
 	case routeEntity:
 		all, err = h.repository.GetRoutes(r.Context())
+		if err == nil {
+			err = h.withShapes(r, all)
+		}
 	case stationEntity:
 		all, err = h.repository.GetStations(r.Context())
+	case calendarEntity:
+		all, err = h.repository.GetCalendars(r.Context())
+	case fareZoneEntity:
+		all, err = h.repository.GetFareZones(r.Context())
 	default:
 		h.doServerError(log, errors.New("wrong entity error"), w)
 		return
@@ -180,6 +206,12 @@ func (h *handlers) GetList(w http.ResponseWriter, r *http.Request, entity string
 		h.doServerError(log, err, w)
 		return
 	}
+	loc, err := h.newLocalizer(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	loc.models(all...)
 
 	log.Info("done ok!")
 	w.WriteHeader(http.StatusOK)
@@ -188,3 +220,29 @@ func (h *handlers) GetList(w http.ResponseWriter, r *http.Request, entity string
 		Items:    all,
 	})
 }
+
+// merge decodes the body onto the stored item, so an update keeps the
+// fields the body leaves out. Entities without optional fields are
+// replaced as a whole.
+func (h *handlers) merge(ctx context.Context, item model.Model, body []byte) (model.Model, error) {
+	if item.GetID() == 0 {
+		return item, nil
+	}
+	var stored model.Model
+	var err error
+	switch item.(type) {
+	case *model.Station:
+		stored, err = h.repository.GetStation(ctx, item.GetID())
+	case *model.Route:
+		stored, err = h.repository.GetRoute(ctx, item.GetID())
+	default:
+		return item, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(body, stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
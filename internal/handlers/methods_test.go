@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// storeRepository keeps one saved item of every entity the tests update.
+type storeRepository struct {
+	model.Repository
+	station *model.Station
+	route   *model.Route
+	updated model.Model
+}
+
+func (r *storeRepository) GetRoute(_ context.Context, id int) (model.Model, error) {
+	rt := *r.route
+	return &rt, nil
+}
+
+func (r *storeRepository) GetStation(_ context.Context, id int) (model.Model, error) {
+	st := *r.station
+	return &st, nil
+}
+
+func (r *storeRepository) Update(_ context.Context, item model.Model) error {
+	r.updated = item
+	return nil
+}
+
+func testHandlers(repo model.Repository) *handlers {
+	return NewHandler(repo, slog.New(slog.NewTextHandler(io.Discard, nil)), nil, time.UTC)
+}
+
+func TestUpdateStationKeepsMissingFields(t *testing.T) {
+	lat, lon, zone := 59.83, 30.37, 2
+	repo := &storeRepository{station: &model.Station{
+		Id: 7, Name: "Kupchino", Lat: &lat, Lon: &lon, ZoneId: &zone,
+		Wheelchair:   model.WheelchairAccessible,
+		Translations: model.Translations{"en": "Kupchino"},
+	}}
+	h := testHandlers(repo)
+
+	tests := []struct {
+		name  string
+		body  string
+		check func(t *testing.T, st *model.Station)
+	}{
+		{
+			name: "rename only",
+			body: `{"id": 7, "name": "Kupchino Station"}`,
+			check: func(t *testing.T, st *model.Station) {
+				if st.Name != "Kupchino Station" || st.Lat == nil || *st.Lat != lat || st.ZoneId == nil ||
+					st.Wheelchair != model.WheelchairAccessible || st.Translations["en"] != "Kupchino" {
+					t.Errorf("updated %+v", st)
+				}
+			},
+		},
+		{
+			name: "explicit values",
+			body: `{"id": 7, "name": "Kupchino", "lat": null, "lon": null, "zone_id": null, "wheelchair": "unknown"}`,
+			check: func(t *testing.T, st *model.Station) {
+				if st.Lat != nil || st.Lon != nil || st.ZoneId != nil || st.Wheelchair != model.WheelchairUnknown {
+					t.Errorf("updated %+v", st)
+				}
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.UpdateStation(w, httptest.NewRequest(http.MethodPut, "/api/stations", strings.NewReader(tc.body)))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+			tc.check(t, repo.updated.(*model.Station))
+		})
+	}
+}
+
+func TestUpdateRouteKeepsPricePerKm(t *testing.T) {
+	price := 12
+	repo := &storeRepository{route: &model.Route{Id: 3, Name: "K-3", PricePerKm: &price}}
+	w := httptest.NewRecorder()
+	testHandlers(repo).UpdateRoute(w, httptest.NewRequest(http.MethodPut, "/api/routes", strings.NewReader(`{"id": 3, "name": "K-3a"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	rt := repo.updated.(*model.Route)
+	if rt.Name != "K-3a" || rt.PricePerKm == nil || *rt.PricePerKm != price {
+		t.Errorf("updated %+v", rt)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const defaultNearbyRadius = 500
+
+type responseNearby struct {
+	response
+	Items []model.NearbyStation `json:"items"`
+}
+
+func (h *handlers) GetNearbyStations(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.GetNearbyStations"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	lat, err := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	lon, err := strconv.ParseFloat(r.URL.Query().Get("lon"), 64)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	radius := float64(defaultNearbyRadius)
+	if v := r.URL.Query().Get("radius"); v != "" {
+		radius, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			h.doServerError(log, err, w)
+			return
+		}
+	}
+
+	items, err := h.repository.GetNearbyStations(r.Context(), lat, lon, radius)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	loc, err := h.newLocalizer(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	for i := range items {
+		items[i].Name = loc.station(items[i].Id, items[i].Name)
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseNearby{
+		response: response{Status: StatusOK},
+		Items:    items,
+	})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// clockParam reads an "HH:MM" query param, falling back to the current time
+// in loc.
+func clockParam(r *http.Request, name string, loc *time.Location) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		now := time.Now().In(loc)
+		return now.Hour()*3600 + now.Minute()*60, nil
+	}
+	return model.ParseClock(v)
+}
+
+// dateParam reads a "YYYY-MM-DD" query param as a date in loc, falling back
+// to today there.
+func dateParam(r *http.Request, name string, loc *time.Location) (time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		now := time.Now().In(loc)
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc), nil
+	}
+	return time.ParseInLocation(model.DateLayout, v, loc)
+}
+
+// boolParam reads a "true"/"false" query param, falling back to false.
+func boolParam(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// urlFormat is the extension of the request path, "csv" for /api/stations.csv.
+func urlFormat(r *http.Request) string {
+	format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string)
+	return format
+}
+
+// separatorParam reads a CSV separator query param, one of "," ";" or
+// "tab", falling back to ",".
+func separatorParam(r *http.Request, name string) (rune, error) {
+	switch v := r.URL.Query().Get(name); v {
+	case "", ",":
+		return ',', nil
+	case ";":
+		return ';', nil
+	case "tab", "\t":
+		return '\t', nil
+	default:
+		return 0, fmt.Errorf("wrong separator: %q", v)
+	}
+}
+
+// uploadBody returns the request body or the "file" field of a multipart
+// form.
+func uploadBody(r *http.Request) (io.ReadCloser, error) {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.Body, nil
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
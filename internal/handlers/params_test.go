@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDateParam(t *testing.T) {
+	agency := time.FixedZone("agency", 10*3600)
+
+	r := httptest.NewRequest("GET", "/api/stations/1/departures?date=2025-03-04", nil)
+	date, err := dateParam(r, "date", agency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2025, 3, 4, 0, 0, 0, 0, agency); !date.Equal(want) || date.Location() != agency {
+		t.Errorf("date %v, want %v", date, want)
+	}
+
+	r = httptest.NewRequest("GET", "/api/stations/1/departures", nil)
+	today, err := dateParam(r, "date", agency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().In(agency)
+	if today.Location() != agency || today.Day() != now.Day() || today.Hour() != 0 {
+		t.Errorf("today %v, want midnight of %v", today, now)
+	}
+}
+
+func TestClockParam(t *testing.T) {
+	agency := time.FixedZone("agency", 10*3600)
+
+	r := httptest.NewRequest("GET", "/api/stations/1/departures?from=08:30", nil)
+	if got, err := clockParam(r, "from", agency); err != nil || got != 8*3600+30*60 {
+		t.Errorf("clock %d, %v, want %d", got, err, 8*3600+30*60)
+	}
+
+	r = httptest.NewRequest("GET", "/api/stations/1/departures", nil)
+	got, err := clockParam(r, "from", agency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().In(agency)
+	if want := now.Hour()*3600 + now.Minute()*60; got != want && got != want-60 {
+		t.Errorf("clock %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type responseReachable struct {
+	response
+	Station model.Model       `json:"station"`
+	Items   []model.Reachable `json:"items"`
+}
+
+func (h *handlers) GetReachable(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.GetReachable"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		err := errors.New("request param id is required")
+		h.doServerError(log, err, w)
+		return
+	}
+	stationId, err := strconv.Atoi(id)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	date, err := dateParam(r, "date", h.location)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	from, err := clockParam(r, "from", h.location)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	budget, err := strconv.Atoi(r.URL.Query().Get("budget"))
+	if err != nil || budget <= 0 {
+		err = errors.New("request param budget must be a positive number of minutes")
+		h.doServerError(log, err, w)
+		return
+	}
+
+	station, err := h.repository.GetStation(r.Context(), stationId)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	items, err := h.service.GetReachable(r.Context(), stationId, date, from, budget*60)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	loc, err := h.newLocalizer(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	loc.models(station)
+	loc.reachable(items)
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseReachable{
+		response: response{Status: StatusOK},
+		Station:  station,
+		Items:    items,
+	})
+}
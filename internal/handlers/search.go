@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const defaultSearchLimit = 10
+
+type responseSearch struct {
+	response
+	Items []model.StationMatch `json:"items"`
+}
+
+func (h *handlers) SearchStations(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.SearchStations"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	limit := defaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil {
+			h.doServerError(log, err, w)
+			return
+		}
+	}
+
+	items, err := h.service.SearchStations(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	loc, err := h.newLocalizer(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	for i := range items {
+		items[i].Name = items[i].Translations.Pick(loc.langs, items[i].Name)
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseSearch{
+		response: response{Status: StatusOK},
+		Items:    items,
+	})
+}
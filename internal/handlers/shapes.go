@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type responseShape struct {
+	response
+	Item model.Shape `json:"item"`
+}
+
+func (h *handlers) GetShape(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.GetShape"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	routeId, err := urlParamInt(r, "id")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	shapes, err := h.repository.GetShapes(r.Context(), routeId)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	item := model.Shape{RouteId: routeId, Points: []model.Point{}}
+	if len(shapes) > 0 {
+		item = shapes[0]
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseShape{
+		response: response{Status: StatusOK},
+		Item:     item,
+	})
+}
+
+// UpdateShape accepts either points or an encoded polyline, points win
+// when both are sent.
+func (h *handlers) UpdateShape(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.UpdateShape"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	routeId, err := urlParamInt(r, "id")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	var item model.Shape
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(r.Body); err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	if err = json.Unmarshal(buf.Bytes(), &item); err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	item.RouteId = routeId
+	if len(item.Points) == 0 && item.Polyline != "" {
+		item.Points, err = model.DecodePolyline(item.Polyline)
+		if err != nil {
+			h.doServerError(log, err, w)
+			return
+		}
+	}
+
+	err = h.repository.SaveShape(r.Context(), &item)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseShape{
+		response: response{Status: StatusOK},
+		Item:     item,
+	})
+}
+
+// withShapes fills Route.Shape when the request asks for it with
+// shape=true.
+func (h *handlers) withShapes(r *http.Request, routes []model.Model) error {
+	withShape, err := boolParam(r, "shape")
+	if err != nil || !withShape {
+		return err
+	}
+
+	routeId := 0
+	if len(routes) == 1 {
+		routeId = routes[0].GetID()
+	}
+	shapes, err := h.repository.GetShapes(r.Context(), routeId)
+	if err != nil {
+		return err
+	}
+	byRoute := make(map[int]*model.Shape, len(shapes))
+	for i := range shapes {
+		byRoute[shapes[i].RouteId] = &shapes[i]
+	}
+	for _, item := range routes {
+		if route, ok := item.(*model.Route); ok {
+			route.Shape = byRoute[route.Id]
+		}
+	}
+	return nil
+}
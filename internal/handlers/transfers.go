@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type responseTransfer struct {
+	response
+	Item model.Transfer `json:"item"`
+}
+
+type responseTransfers struct {
+	response
+	Items []model.Transfer `json:"items"`
+}
+
+func (h *handlers) GetTransfers(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.GetTransfers"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	items, err := h.repository.GetTransfers(r.Context())
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseTransfers{
+		response: response{Status: StatusOK},
+		Items:    items,
+	})
+}
+
+func (h *handlers) SuggestTransfers(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.SuggestTransfers"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	var radius float64
+	if v := r.URL.Query().Get("radius"); v != "" {
+		var err error
+		radius, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			h.doServerError(log, err, w)
+			return
+		}
+	}
+
+	items, err := h.service.SuggestTransfers(r.Context(), radius)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseTransfers{
+		response: response{Status: StatusOK},
+		Items:    items,
+	})
+}
+
+func (h *handlers) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.CreateTransfer"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	item, err := readTransfer(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	item.Id = 0
+
+	err = h.repository.CreateTransfer(r.Context(), &item)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(responseTransfer{
+		response: response{Status: StatusOK},
+		Item:     item,
+	})
+}
+
+func (h *handlers) UpdateTransfer(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.UpdateTransfer"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	item, err := readTransfer(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	err = h.repository.UpdateTransfer(r.Context(), &item)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	h.responseOK(w)
+}
+
+func (h *handlers) DeleteTransfer(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.DeleteTransfer"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	id, err := urlParamInt(r, "id")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	err = h.repository.DeleteTransfer(r.Context(), id)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	h.responseOK(w)
+}
+
+func readTransfer(r *http.Request) (model.Transfer, error) {
+	var item model.Transfer
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r.Body); err != nil {
+		return item, err
+	}
+	if err := json.Unmarshal(buf.Bytes(), &item); err != nil {
+		return item, err
+	}
+
+	if item.FromId == item.ToId {
+		return item, errors.New("from_id and to_id must be different stations")
+	}
+	if item.WalkMinutes <= 0 {
+		return item, errors.New("walk_minutes must be a positive number")
+	}
+	return item, nil
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type responseTrip struct {
+	response
+	Item model.Trip `json:"item"`
+}
+
+type responseTrips struct {
+	response
+	Items []model.Trip `json:"items"`
+}
+
+func urlParamInt(r *http.Request, name string) (int, error) {
+	v := chi.URLParam(r, name)
+	if v == "" {
+		return 0, errors.New("request param " + name + " is required")
+	}
+	return strconv.Atoi(v)
+}
+
+func (h *handlers) GetTrips(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.GetTrips"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	routeId, err := urlParamInt(r, "id")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	items, err := h.repository.GetTrips(r.Context(), routeId)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseTrips{
+		response: response{Status: StatusOK},
+		Items:    items,
+	})
+}
+
+func (h *handlers) GetTrip(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.GetTrip"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	routeId, err := urlParamInt(r, "id")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	tripId, err := urlParamInt(r, "tripId")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	item, err := h.repository.GetTrip(r.Context(), routeId, tripId)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseTrip{
+		response: response{Status: StatusOK},
+		Item:     item,
+	})
+}
+
+func (h *handlers) CreateTrip(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.CreateTrip"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	item, err := h.readTrip(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	err = h.repository.CreateTrip(r.Context(), &item)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(responseTrip{
+		response: response{Status: StatusOK},
+		Item:     item,
+	})
+}
+
+func (h *handlers) UpdateTrip(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.UpdateTrip"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	item, err := h.readTrip(r)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	err = h.repository.UpdateTrip(r.Context(), &item)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	h.responseOK(w)
+}
+
+func (h *handlers) DeleteTrip(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.DeleteTrip"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	routeId, err := urlParamInt(r, "id")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	tripId, err := urlParamInt(r, "tripId")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	err = h.repository.DeleteTrip(r.Context(), routeId, tripId)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	h.responseOK(w)
+}
+
+func (h *handlers) GenerateTrips(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.GenerateTrips"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	routeId, err := urlParamInt(r, "id")
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(r.Body); err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+	var pattern model.TripPattern
+	if err = json.Unmarshal(buf.Bytes(), &pattern); err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	items, err := h.service.GenerateTrips(r.Context(), routeId, pattern)
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(responseTrips{
+		response: response{Status: StatusOK},
+		Items:    items,
+	})
+}
+
+// readTrip decodes the request body, takes the route from the url and
+// normalizes the stop times to HH:MM:SS.
+func (h *handlers) readTrip(r *http.Request) (model.Trip, error) {
+	var item model.Trip
+
+	routeId, err := urlParamInt(r, "id")
+	if err != nil {
+		return item, err
+	}
+
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(r.Body); err != nil {
+		return item, err
+	}
+	if err = json.Unmarshal(buf.Bytes(), &item); err != nil {
+		return item, err
+	}
+	item.RouteId = routeId
+
+	for i, st := range item.StopTimes {
+		t, err := model.ParseClock(st.Time)
+		if err != nil {
+			return item, err
+		}
+		item.StopTimes[i].Time = model.FormatClock(t)
+	}
+	return item, nil
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type responseVehicles struct {
+	response
+	Items []model.VehiclePosition `json:"items"`
+}
+
+// GetVehicles lists the last vehicle positions of the realtime feed.
+func (h *handlers) GetVehicles(w http.ResponseWriter, r *http.Request) {
+	log := h.logger.With(
+		slog.String("api", "handlers.GetVehicles"),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+	w.Header().Set("Content-Type", contentType)
+
+	items, err := h.service.GetVehicles(r.Context())
+	if err != nil {
+		h.doServerError(log, err, w)
+		return
+	}
+
+	log.Info("done ok!")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(responseVehicles{
+		response: response{Status: StatusOK},
+		Items:    items,
+	})
+}
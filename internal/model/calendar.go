@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+const DateLayout = "2006-01-02"
+
+type Calendar struct {
+	Id         int            `json:"id"`
+	Name       string         `json:"name"`
+	Monday     bool           `json:"monday"`
+	Tuesday    bool           `json:"tuesday"`
+	Wednesday  bool           `json:"wednesday"`
+	Thursday   bool           `json:"thursday"`
+	Friday     bool           `json:"friday"`
+	Saturday   bool           `json:"saturday"`
+	Sunday     bool           `json:"sunday"`
+	StartDate  string         `json:"start_date"`
+	EndDate    string         `json:"end_date"`
+	Exceptions []CalendarDate `json:"exceptions"`
+}
+
+// CalendarDate adds (Added=true) or removes service on a single date.
+type CalendarDate struct {
+	Date  string `json:"date"`
+	Added bool   `json:"added"`
+}
+
+func (r *Calendar) GetID() int {
+	return r.Id
+}
+
+func (r *Calendar) GetName() string {
+	return r.Name
+}
+
+func (r *Calendar) SetID(id int) {
+	r.Id = id
+}
+
+func (r *Calendar) SetName(name string) {
+	r.Name = name
+}
+
+func (r *Calendar) DBTable() string {
+	return "calendar"
+}
+
+// Active reports whether the calendar runs on the given service date.
+func (r *Calendar) Active(date time.Time) bool {
+	day := date.Format(DateLayout)
+	for _, e := range r.Exceptions {
+		if e.Date == day {
+			return e.Added
+		}
+	}
+	if r.StartDate != "" && day < r.StartDate {
+		return false
+	}
+	if r.EndDate != "" && day > r.EndDate {
+		return false
+	}
+
+	switch date.Weekday() {
+	case time.Monday:
+		return r.Monday
+	case time.Tuesday:
+		return r.Tuesday
+	case time.Wednesday:
+		return r.Wednesday
+	case time.Thursday:
+		return r.Thursday
+	case time.Friday:
+		return r.Friday
+	case time.Saturday:
+		return r.Saturday
+	default:
+		return r.Sunday
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxClock is the end of the second service day: times from 24:00:00 on
+// belong to trips that started before midnight.
+const MaxClock = 48 * 3600
+
+// ParseClock converts "HH:MM" or "HH:MM:SS" into seconds since the start of
+// the service day, hours up to 47 are allowed for after-midnight trips.
+func ParseClock(s string) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("wrong time format: %q", s)
+	}
+	values := make([]int, 3)
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("wrong time format: %q", s)
+		}
+		values[i] = v
+	}
+	h, m, sec := values[0], values[1], values[2]
+	if h*3600 >= MaxClock || m > 59 || sec > 59 {
+		return 0, fmt.Errorf("wrong time format: %q", s)
+	}
+	return h*3600 + m*60 + sec, nil
+}
+
+// FormatClock converts seconds since the start of the service day into
+// "HH:MM:SS", keeping hours past 23 for after-midnight trips.
+func FormatClock(sec int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", sec/3600, sec%3600/60, sec%60)
+}
@@ -0,0 +1,15 @@
+package model
+
+// CSVReport is the result of a CSV import, rows with errors are skipped and
+// the other rows are saved.
+type CSVReport struct {
+	Created int        `json:"created"`
+	Updated int        `json:"updated"`
+	Errors  []RowError `json:"errors"`
+}
+
+type RowError struct {
+	// Line is the line of the row in the file, the header is line 1
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
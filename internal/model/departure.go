@@ -0,0 +1,15 @@
+package model
+
+type Departure struct {
+	RouteId     int        `json:"route_id"`
+	RouteName   string     `json:"route_name"`
+	Headsign    string     `json:"headsign"`
+	Time        string     `json:"time"`
+	TripId      int        `json:"trip_id,omitempty"`
+	FrequencyId int        `json:"frequency_id,omitempty"`
+	Queue       int        `json:"queue"`
+	Wheelchair  Wheelchair `json:"wheelchair"`
+	// Predicted comes from the realtime feed, Canceled buses do not stop
+	Predicted string `json:"predicted,omitempty"`
+	Canceled  bool   `json:"canceled,omitempty"`
+}
@@ -0,0 +1,43 @@
+package model
+
+type FareZone struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (r *FareZone) GetID() int {
+	return r.Id
+}
+
+func (r *FareZone) GetName() string {
+	return r.Name
+}
+
+func (r *FareZone) SetID(id int) {
+	r.Id = id
+}
+
+func (r *FareZone) SetName(name string) {
+	r.Name = name
+}
+
+func (r *FareZone) DBTable() string {
+	return "fare_zone"
+}
+
+// ZonePrice is the price of a ride that starts in one zone and ends in the
+// other. Prices are in minor currency units, kopecks for RUB.
+type ZonePrice struct {
+	FromZoneId int `json:"from_zone_id"`
+	ToZoneId   int `json:"to_zone_id"`
+	Price      int `json:"price"`
+}
+
+// Fare amounts are in minor currency units, Distance is in kilometers.
+type Fare struct {
+	Amount        int     `json:"amount"`
+	Currency      string  `json:"currency"`
+	ZonePrice     int     `json:"zone_price,omitempty"`
+	DistancePrice int     `json:"distance_price,omitempty"`
+	Distance      float64 `json:"distance,omitempty"`
+}
@@ -0,0 +1,45 @@
+package model
+
+// Feed is a whole timetable ready to be stored, its parts refer to each
+// other by index in the slices instead of database ids.
+type Feed struct {
+	Stations  []Station
+	Calendars []Calendar
+	Routes    []FeedRoute
+}
+
+type FeedRoute struct {
+	Name string
+	// Stations are indexes into Feed.Stations in stop order
+	Stations []int
+	// Trips are ordered by departure, the position is the trip queue
+	Trips []FeedTrip
+}
+
+type FeedTrip struct {
+	// Calendar is an index into Feed.Calendars
+	Calendar   int
+	Headsign   string
+	Wheelchair Wheelchair
+	// Times in seconds since the start of the service day, one for every
+	// route station
+	Times []int
+}
+
+type ImportOptions struct {
+	// DryRun runs the whole import and rolls it back
+	DryRun bool
+	// Replace drops the existing stations, routes and calendars first
+	Replace bool
+}
+
+type ImportReport struct {
+	DryRun    bool     `json:"dry_run"`
+	Replace   bool     `json:"replace"`
+	Stations  int      `json:"stations"`
+	Calendars int      `json:"calendars"`
+	Routes    int      `json:"routes"`
+	Trips     int      `json:"trips"`
+	StopTimes int      `json:"stop_times"`
+	Warnings  []string `json:"warnings"`
+}
@@ -0,0 +1,24 @@
+package model
+
+// Frequency describes runs that leave every Headway minutes from StartTime
+// until EndTime (exclusive), Offsets are minutes from the run start.
+type Frequency struct {
+	Id        int    `json:"id"`
+	RouteId   int    `json:"route_id"`
+	RouteName string `json:"route_name"`
+	// CalendarId is nil for frequencies that apply every day
+	CalendarId *int              `json:"calendar_id"`
+	StartTime  string            `json:"start_time"`
+	EndTime    string            `json:"end_time"`
+	Headway    int               `json:"headway"`
+	Wheelchair Wheelchair        `json:"wheelchair"`
+	Offsets    []FrequencyOffset `json:"offsets"`
+}
+
+type FrequencyOffset struct {
+	RouteStationId int    `json:"route_station_id"`
+	StationId      int    `json:"station_id"`
+	StationName    string `json:"station_name"`
+	Pos            int    `json:"pos"`
+	Minutes        int    `json:"minutes"`
+}
@@ -0,0 +1,97 @@
+package model
+
+import "time"
+
+type RouteStation struct {
+	Id          int      `json:"id"`
+	RouteId     int      `json:"route_id"`
+	RouteName   string   `json:"route_name"`
+	StationId   int      `json:"station_id"`
+	StationName string   `json:"station_name"`
+	Pos         int      `json:"pos"`
+	ZoneId      *int     `json:"zone_id,omitempty"`
+	Lat         *float64 `json:"lat,omitempty"`
+	Lon         *float64 `json:"lon,omitempty"`
+}
+
+type StopTime struct {
+	RouteStationId int    `json:"route_station_id"`
+	RouteId        int    `json:"route_id"`
+	RouteName      string `json:"route_name"`
+	StationId      int    `json:"station_id"`
+	StationName    string `json:"station_name"`
+	Pos            int    `json:"pos"`
+	TripId         int    `json:"trip_id"`
+	FrequencyId    int    `json:"frequency_id"`
+	Queue          int    `json:"queue"`
+	Headsign       string `json:"headsign"`
+	// Time is seconds since the start of the service day, it may go past
+	// 24:00:00 for night trips
+	Time int `json:"time"`
+	// CalendarId is 0 for trips that run every day
+	CalendarId int `json:"calendar_id"`
+	// DayOffset is -1 for trips of the previous service day shifted onto
+	// the requested one
+	DayOffset  int        `json:"day_offset"`
+	Wheelchair Wheelchair `json:"wheelchair"`
+	// Predicted is the realtime time in the same frame as Time, nil without
+	// a prediction
+	Predicted *int `json:"predicted,omitempty"`
+	// Canceled is set when the trip is canceled or skips this stop
+	Canceled bool `json:"canceled,omitempty"`
+}
+
+type JourneyQuery struct {
+	FromId int
+	ToId   int
+	// MaxTransfers < 0 means the configured default
+	MaxTransfers int
+	// DepartAt and ArriveBy are seconds since midnight, at most one of them
+	// is set. Both nil means a timetable-free search.
+	DepartAt *int
+	ArriveBy *int
+	// Date is the service day of a timed search
+	Date time.Time
+	// ViaIds are passed in the given order, AvoidIds are never stopped at
+	ViaIds   []int
+	AvoidIds []int
+	// Wheelchair limits the search to accessible trips and stations
+	Wheelchair bool
+}
+
+type Place struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type Leg struct {
+	RouteId     int    `json:"route_id"`
+	RouteName   string `json:"route_name"`
+	Board       Place  `json:"board"`
+	Alight      Place  `json:"alight"`
+	Stops       int    `json:"stops"`
+	Transfer    *Place `json:"transfer,omitempty"`
+	TripId      int    `json:"trip_id,omitempty"`
+	FrequencyId int    `json:"frequency_id,omitempty"`
+	Queue       *int   `json:"queue,omitempty"`
+	Departure   string `json:"departure,omitempty"`
+	Arrival     string `json:"arrival,omitempty"`
+	// PredictedDeparture and PredictedArrival come from the realtime feed
+	PredictedDeparture string `json:"predicted_departure,omitempty"`
+	PredictedArrival   string `json:"predicted_arrival,omitempty"`
+	// Walk legs are walking transfers between nearby stations, they have no
+	// route
+	Walk        bool `json:"walk,omitempty"`
+	WalkMinutes int  `json:"walk_minutes,omitempty"`
+}
+
+type Journey struct {
+	Legs        []Leg    `json:"legs"`
+	Transfers   int      `json:"transfers"`
+	Departure   string   `json:"departure,omitempty"`
+	Arrival     string   `json:"arrival,omitempty"`
+	WaitMinutes int      `json:"wait_minutes"`
+	Labels      []string `json:"labels,omitempty"`
+	// Fare is nil when there is no fare rule for one of the legs
+	Fare *Fare `json:"fare,omitempty"`
+}
@@ -0,0 +1,8 @@
+package model
+
+type Reachable struct {
+	Station   Place  `json:"station"`
+	Arrival   string `json:"arrival"`
+	Minutes   int    `json:"minutes"`
+	Transfers int    `json:"transfers"`
+}
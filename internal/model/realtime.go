@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// TripUpdate is the realtime state of one stored trip.
+type TripUpdate struct {
+	TripId int `json:"trip_id"`
+	// StartDate is the service day the update is for
+	StartDate string `json:"start_date"`
+	Canceled  bool   `json:"canceled"`
+	// Delay in seconds of the whole trip, used for the stops no stop update
+	// covers
+	Delay     *int         `json:"delay,omitempty"`
+	Stops     []StopUpdate `json:"stops"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// StopUpdate carries either a delay or a predicted time, the delay also
+// applies to the following stops until the next update.
+type StopUpdate struct {
+	// Pos is -1 when the feed gave the station only
+	Pos       int  `json:"pos"`
+	StationId int  `json:"station_id"`
+	Skipped   bool `json:"skipped,omitempty"`
+	// Delay in seconds
+	Delay *int `json:"delay,omitempty"`
+	// Time in seconds since the start of the service day
+	Time *int `json:"time,omitempty"`
+}
+
+type VehiclePosition struct {
+	TripId    int      `json:"trip_id"`
+	VehicleId string   `json:"vehicle_id,omitempty"`
+	Lat       float64  `json:"lat"`
+	Lon       float64  `json:"lon"`
+	Bearing   *float64 `json:"bearing,omitempty"`
+	// Pos is the stop the vehicle is at or heading to, nil when unknown
+	Pos       *int      `json:"pos,omitempty"`
+	StationId int       `json:"station_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
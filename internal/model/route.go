@@ -4,6 +4,12 @@ type Route struct {
 	Id       int       `json:"id"`
 	Name     string    `json:"name"`
 	Stations []Station `json:"stations"`
+	Shape    *Shape    `json:"shape,omitempty"`
+	// PricePerKm in minor currency units is charged on top of the zone
+	// price, nil when the route has no distance component
+	PricePerKm *int `json:"price_per_km,omitempty"`
+	// Translations are never changed by an update that leaves them out
+	Translations Translations `json:"translations,omitempty"`
 }
 
 func (r *Route) GetID() int {
@@ -0,0 +1,7 @@
+package model
+
+type StationMatch struct {
+	Station
+	// Score is the similarity to the query from 0 to 1
+	Score float64 `json:"score"`
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+const polylinePrecision = 1e5
+
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Shape is the path a route takes on the map, Polyline is the same points
+// in the encoded polyline format.
+type Shape struct {
+	RouteId  int     `json:"route_id"`
+	Points   []Point `json:"points"`
+	Polyline string  `json:"polyline"`
+}
+
+// EncodePolyline encodes the points with the polyline algorithm used by map
+// services, five decimal places of precision.
+func EncodePolyline(points []Point) string {
+	var sb strings.Builder
+	var prevLat, prevLon int
+	for _, p := range points {
+		lat := int(math.Round(p.Lat * polylinePrecision))
+		lon := int(math.Round(p.Lon * polylinePrecision))
+		encodePolylineValue(&sb, lat-prevLat)
+		encodePolylineValue(&sb, lon-prevLon)
+		prevLat, prevLon = lat, lon
+	}
+	return sb.String()
+}
+
+func encodePolylineValue(sb *strings.Builder, v int) {
+	v <<= 1
+	if v < 0 {
+		v = ^v
+	}
+	for v >= 0x20 {
+		sb.WriteByte(byte((0x20 | (v & 0x1f)) + 63))
+		v >>= 5
+	}
+	sb.WriteByte(byte(v + 63))
+}
+
+func DecodePolyline(s string) ([]Point, error) {
+	points := make([]Point, 0)
+	var lat, lon int
+	for i := 0; i < len(s); {
+		dLat, n, err := decodePolylineValue(s[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		dLon, n, err := decodePolylineValue(s[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		lat += dLat
+		lon += dLon
+		points = append(points, Point{Lat: float64(lat) / polylinePrecision, Lon: float64(lon) / polylinePrecision})
+	}
+	return points, nil
+}
+
+func decodePolylineValue(s string) (int, int, error) {
+	var result, shift int
+	for i := 0; i < len(s); i++ {
+		b := int(s[i]) - 63
+		if b < 0 || b > 0x3f {
+			return 0, 0, errors.New("wrong polyline")
+		}
+		result |= (b & 0x1f) << shift
+		shift += 5
+		if b < 0x20 {
+			if result&1 != 0 {
+				return ^(result >> 1), i + 1, nil
+			}
+			return result >> 1, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("wrong polyline")
+}
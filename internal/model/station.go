@@ -1,9 +1,21 @@
 package model
 
 type Station struct {
-	Id       int      `json:"id"`
-	Name     string   `json:"name"`
-	StopTime []string `json:"stop_time"`
+	Id         int        `json:"id"`
+	Name       string     `json:"name"`
+	StopTime   []string   `json:"stop_time"`
+	Lat        *float64   `json:"lat,omitempty"`
+	Lon        *float64   `json:"lon,omitempty"`
+	ZoneId     *int       `json:"zone_id,omitempty"`
+	Wheelchair Wheelchair `json:"wheelchair"`
+	// Translations are never changed by an update that leaves them out
+	Translations Translations `json:"translations,omitempty"`
+}
+
+type NearbyStation struct {
+	Station
+	// Distance in meters
+	Distance float64 `json:"distance"`
 }
 
 func (r *Station) GetID() int {
@@ -10,7 +10,36 @@ type Repository interface {
 	GetRoute(ctx context.Context, id int) (Model, error)
 	GetStation(ctx context.Context, id int) (Model, error)
 	GetStations(ctx context.Context) ([]Model, error)
+	// GetStationsVersion changes whenever a station or its translation does
+	GetStationsVersion(ctx context.Context) (int64, error)
+	GetNearbyStations(ctx context.Context, lat float64, lon float64, radius float64) ([]NearbyStation, error)
+	GetCalendar(ctx context.Context, id int) (Model, error)
+	GetCalendars(ctx context.Context) ([]Model, error)
 	Update(ctx context.Context, r Model) error
 	Delete(ctx context.Context, r Model) error
-	FindBus(ctx context.Context, fromId int, toId int) ([]Model, error)
+	GetTrips(ctx context.Context, routeId int) ([]Trip, error)
+	GetTrip(ctx context.Context, routeId int, id int) (Trip, error)
+	CreateTrip(ctx context.Context, item *Trip) error
+	CreateTrips(ctx context.Context, items []*Trip) error
+	UpdateTrip(ctx context.Context, item *Trip) error
+	DeleteTrip(ctx context.Context, routeId int, id int) error
+	GetFrequencies(ctx context.Context, routeId int) ([]Frequency, error)
+	CreateFrequency(ctx context.Context, item *Frequency) error
+	UpdateFrequency(ctx context.Context, item *Frequency) error
+	DeleteFrequency(ctx context.Context, routeId int, id int) error
+	GetRouteStations(ctx context.Context) ([]RouteStation, error)
+	GetStopTimes(ctx context.Context) ([]StopTime, error)
+	GetTransfers(ctx context.Context) ([]Transfer, error)
+	CreateTransfer(ctx context.Context, item *Transfer) error
+	UpdateTransfer(ctx context.Context, item *Transfer) error
+	DeleteTransfer(ctx context.Context, id int) error
+	GetShapes(ctx context.Context, routeId int) ([]Shape, error)
+	SaveShape(ctx context.Context, item *Shape) error
+	GetTranslations(ctx context.Context, table string) (map[int]Translations, error)
+	GetFareZone(ctx context.Context, id int) (Model, error)
+	GetFareZones(ctx context.Context) ([]Model, error)
+	GetZonePrices(ctx context.Context) ([]ZonePrice, error)
+	SaveZonePrice(ctx context.Context, item ZonePrice) error
+	DeleteZonePrice(ctx context.Context, fromZoneId int, toZoneId int) error
+	ImportFeed(ctx context.Context, feed *Feed, opts ImportOptions) error
 }
@@ -0,0 +1,11 @@
+package model
+
+// Transfer is a one-way walk between two nearby stations.
+type Transfer struct {
+	Id          int    `json:"id"`
+	FromId      int    `json:"from_id"`
+	FromName    string `json:"from_name"`
+	ToId        int    `json:"to_id"`
+	ToName      string `json:"to_name"`
+	WalkMinutes int    `json:"walk_minutes"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "strings"
+
+// Translations maps a language code such as "en" or "zh-hans" to a
+// translated name.
+type Translations map[string]string
+
+// Pick returns the translation for the first language that has one, a
+// regional code like "en-US" also matches "en". It falls back to name.
+func (t Translations) Pick(langs []string, name string) string {
+	for _, lang := range langs {
+		lang = strings.ToLower(lang)
+		if v, ok := t[lang]; ok {
+			return v
+		}
+		if base, _, found := strings.Cut(lang, "-"); found {
+			if v, ok := t[base]; ok {
+				return v
+			}
+		}
+	}
+	return name
+}
@@ -0,0 +1,34 @@
+package model
+
+type Trip struct {
+	Id      int `json:"id"`
+	RouteId int `json:"route_id"`
+	Queue   int `json:"queue"`
+	// CalendarId is nil for trips that run every day
+	CalendarId *int           `json:"calendar_id"`
+	Headsign   string         `json:"headsign"`
+	Wheelchair Wheelchair     `json:"wheelchair"`
+	StopTimes  []TripStopTime `json:"stop_times"`
+}
+
+type TripStopTime struct {
+	StationId   int    `json:"station_id"`
+	StationName string `json:"station_name"`
+	Pos         int    `json:"pos"`
+	Time        string `json:"time"`
+}
+
+// TripPattern generates one trip per departure, every stop time is the
+// departure plus the minutes offset of the station at Pos.
+type TripPattern struct {
+	Departures []string    `json:"departures"`
+	Offsets    []PosOffset `json:"offsets"`
+	CalendarId *int        `json:"calendar_id"`
+	Headsign   string      `json:"headsign"`
+	Wheelchair Wheelchair  `json:"wheelchair"`
+}
+
+type PosOffset struct {
+	Pos     int `json:"pos"`
+	Minutes int `json:"minutes"`
+}
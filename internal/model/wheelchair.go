@@ -0,0 +1,37 @@
+package model
+
+import "fmt"
+
+// Wheelchair uses the GTFS wheelchair_boarding values, it is written to
+// JSON as "unknown", "accessible" or "not_accessible".
+type Wheelchair int
+
+const (
+	WheelchairUnknown Wheelchair = iota
+	WheelchairAccessible
+	WheelchairNotAccessible
+)
+
+var wheelchairNames = map[Wheelchair]string{
+	WheelchairUnknown:       "unknown",
+	WheelchairAccessible:    "accessible",
+	WheelchairNotAccessible: "not_accessible",
+}
+
+func (w Wheelchair) MarshalText() ([]byte, error) {
+	name, ok := wheelchairNames[w]
+	if !ok {
+		return nil, fmt.Errorf("wrong wheelchair value: %d", w)
+	}
+	return []byte(name), nil
+}
+
+func (w *Wheelchair) UnmarshalText(text []byte) error {
+	for value, name := range wheelchairNames {
+		if name == string(text) {
+			*w = value
+			return nil
+		}
+	}
+	return fmt.Errorf("wrong wheelchair value: %q", text)
+}
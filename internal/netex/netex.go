@@ -0,0 +1,210 @@
+package netex
+
+import "encoding/xml"
+
+// The types below are the part of the NeTEx schema the export fills, the
+// fields follow the element order of the XSD.
+
+const (
+	namespace     = "http://www.netex.org.uk/netex"
+	schemaVersion = "1.1"
+)
+
+// entity holds the attributes every versioned NeTEx object has.
+type entity struct {
+	Id      string `xml:"id,attr"`
+	Version string `xml:"version,attr"`
+}
+
+type ref struct {
+	Ref string `xml:"ref,attr"`
+}
+
+type publicationDelivery struct {
+	XMLName     xml.Name       `xml:"PublicationDelivery"`
+	Xmlns       string         `xml:"xmlns,attr"`
+	Version     string         `xml:"version,attr"`
+	Timestamp   string         `xml:"PublicationTimestamp"`
+	Participant string         `xml:"ParticipantRef"`
+	Frame       compositeFrame `xml:"dataObjects>CompositeFrame"`
+}
+
+type compositeFrame struct {
+	entity
+	ValidBetween  validBetween         `xml:"ValidBetween"`
+	Codespace     codespace            `xml:"codespaces>Codespace"`
+	Locale        locale               `xml:"FrameDefaults>DefaultLocale"`
+	Resource      resourceFrame        `xml:"frames>ResourceFrame"`
+	Site          siteFrame            `xml:"frames>SiteFrame"`
+	Service       serviceFrame         `xml:"frames>ServiceFrame"`
+	Calendar      serviceCalendarFrame `xml:"frames>ServiceCalendarFrame"`
+	TimetableData timetableFrame       `xml:"frames>TimetableFrame"`
+}
+
+type validBetween struct {
+	FromDate string `xml:"FromDate"`
+	ToDate   string `xml:"ToDate,omitempty"`
+}
+
+type codespace struct {
+	Id       string `xml:"id,attr"`
+	Xmlns    string `xml:"Xmlns"`
+	XmlnsUrl string `xml:"XmlnsUrl"`
+}
+
+type locale struct {
+	TimeZone string `xml:"TimeZone"`
+	Language string `xml:"DefaultLanguage"`
+}
+
+type resourceFrame struct {
+	entity
+	Operator operator `xml:"organisations>Operator"`
+}
+
+type operator struct {
+	entity
+	Name string `xml:"Name"`
+	Url  string `xml:"ContactDetails>Url,omitempty"`
+}
+
+type siteFrame struct {
+	entity
+	StopPlaces []stopPlace `xml:"stopPlaces>StopPlace"`
+}
+
+type stopPlace struct {
+	entity
+	Name          string                  `xml:"Name"`
+	Centroid      *location               `xml:"Centroid>Location"`
+	Accessibility accessibilityAssessment `xml:"AccessibilityAssessment"`
+	TransportMode string                  `xml:"TransportMode"`
+	Type          string                  `xml:"StopPlaceType"`
+}
+
+type location struct {
+	Longitude float64 `xml:"Longitude"`
+	Latitude  float64 `xml:"Latitude"`
+}
+
+type accessibilityAssessment struct {
+	entity
+	MobilityImpairedAccess string `xml:"MobilityImpairedAccess"`
+	WheelchairAccess       string `xml:"limitations>AccessibilityLimitation>WheelchairAccess"`
+}
+
+type serviceFrame struct {
+	entity
+	RoutePoints         []routePoint              `xml:"routePoints>RoutePoint"`
+	Routes              []route                   `xml:"routes>Route"`
+	Lines               []line                    `xml:"lines>Line"`
+	ScheduledStopPoints []scheduledStopPoint      `xml:"scheduledStopPoints>ScheduledStopPoint"`
+	StopAssignments     []passengerStopAssignment `xml:"stopAssignments>PassengerStopAssignment"`
+	JourneyPatterns     []serviceJourneyPattern   `xml:"journeyPatterns>ServiceJourneyPattern"`
+}
+
+type routePoint struct {
+	entity
+	Projection pointProjection `xml:"projections>PointProjection"`
+}
+
+type pointProjection struct {
+	entity
+	ProjectToPointRef ref `xml:"ProjectToPointRef"`
+}
+
+type route struct {
+	entity
+	Name   string         `xml:"Name"`
+	Line   ref            `xml:"LineRef"`
+	Points []pointOnRoute `xml:"pointsInSequence>PointOnRoute"`
+}
+
+type pointOnRoute struct {
+	entity
+	Order      int `xml:"order,attr"`
+	RoutePoint ref `xml:"RoutePointRef"`
+}
+
+type line struct {
+	entity
+	Name          string `xml:"Name"`
+	TransportMode string `xml:"TransportMode"`
+	PublicCode    string `xml:"PublicCode"`
+	Operator      ref    `xml:"OperatorRef"`
+}
+
+type scheduledStopPoint struct {
+	entity
+	Name string `xml:"Name"`
+}
+
+type passengerStopAssignment struct {
+	entity
+	Order              int `xml:"order,attr"`
+	ScheduledStopPoint ref `xml:"ScheduledStopPointRef"`
+	StopPlace          ref `xml:"StopPlaceRef"`
+}
+
+type serviceJourneyPattern struct {
+	entity
+	Name   string                      `xml:"Name"`
+	Route  ref                         `xml:"RouteRef"`
+	Points []stopPointInJourneyPattern `xml:"pointsInSequence>StopPointInJourneyPattern"`
+}
+
+type stopPointInJourneyPattern struct {
+	entity
+	Order              int `xml:"order,attr"`
+	ScheduledStopPoint ref `xml:"ScheduledStopPointRef"`
+}
+
+type serviceCalendarFrame struct {
+	entity
+	DayTypes           []dayType           `xml:"dayTypes>DayType"`
+	OperatingPeriods   []operatingPeriod   `xml:"operatingPeriods>OperatingPeriod"`
+	DayTypeAssignments []dayTypeAssignment `xml:"dayTypeAssignments>DayTypeAssignment"`
+}
+
+type dayType struct {
+	entity
+	Name       string `xml:"Name"`
+	DaysOfWeek string `xml:"properties>PropertyOfDay>DaysOfWeek"`
+}
+
+type operatingPeriod struct {
+	entity
+	FromDate string `xml:"FromDate"`
+	ToDate   string `xml:"ToDate"`
+}
+
+type dayTypeAssignment struct {
+	entity
+	Order           int    `xml:"order,attr"`
+	OperatingPeriod *ref   `xml:"OperatingPeriodRef"`
+	Date            string `xml:"Date,omitempty"`
+	DayType         ref    `xml:"DayTypeRef"`
+	IsAvailable     *bool  `xml:"isAvailable"`
+}
+
+type timetableFrame struct {
+	entity
+	Journeys []serviceJourney `xml:"vehicleJourneys>ServiceJourney"`
+}
+
+type serviceJourney struct {
+	entity
+	DayTypes       []ref                   `xml:"dayTypes>DayTypeRef"`
+	JourneyPattern ref                     `xml:"JourneyPatternRef"`
+	Operator       ref                     `xml:"OperatorRef"`
+	PassingTimes   []timetabledPassingTime `xml:"passingTimes>TimetabledPassingTime"`
+}
+
+type timetabledPassingTime struct {
+	entity
+	StopPoint          ref    `xml:"StopPointInJourneyPatternRef"`
+	ArrivalTime        string `xml:"ArrivalTime,omitempty"`
+	ArrivalDayOffset   int    `xml:"ArrivalDayOffset,omitempty"`
+	DepartureTime      string `xml:"DepartureTime,omitempty"`
+	DepartureDayOffset int    `xml:"DepartureDayOffset,omitempty"`
+}
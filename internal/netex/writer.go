@@ -0,0 +1,324 @@
+package netex
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/config"
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+const (
+	objectVersion = "1"
+	dateLayout    = "2006-01-02T15:04:05"
+	busMode       = "bus"
+	day           = 24 * 3600
+	// dailyService is the day type of trips without a calendar
+	dailyService = "daily"
+)
+
+// Timetable is the stored network to export, database ids become the
+// object ids after the codespace so they stay the same between exports.
+type Timetable struct {
+	Stations []*model.Station
+	Routes   []*model.Route
+	// RouteStations are ordered by route and position
+	RouteStations []model.RouteStation
+	Calendars     []*model.Calendar
+	// StopTimes are ordered by trip and position
+	StopTimes []model.StopTime
+	Start     time.Time
+}
+
+type writer struct {
+	codespace string
+}
+
+func (w writer) id(kind string, key any) string {
+	return fmt.Sprintf("%s:%s:%v", w.codespace, kind, key)
+}
+
+func (w writer) entity(kind string, key any) entity {
+	return entity{Id: w.id(kind, key), Version: objectVersion}
+}
+
+func (w writer) ref(kind string, key any) ref {
+	return ref{Ref: w.id(kind, key)}
+}
+
+// Write encodes the timetable as one NeTEx PublicationDelivery. Every
+// station is a StopPlace with a ScheduledStopPoint assigned to it, every
+// route a Line with one Route and ServiceJourneyPattern over its stations
+// and every stored trip a ServiceJourney.
+func Write(out io.Writer, agency config.GTFS, t Timetable) error {
+	w := writer{codespace: agency.Codespace}
+	timetable, daily := w.timetableFrame(t)
+
+	delivery := publicationDelivery{
+		Xmlns:       namespace,
+		Version:     schemaVersion,
+		Timestamp:   time.Now().Format(dateLayout),
+		Participant: agency.Codespace,
+		Frame: compositeFrame{
+			entity:       w.entity("CompositeFrame", 1),
+			ValidBetween: validBetween{FromDate: startOfDay(t.Start).Format(dateLayout)},
+			Codespace: codespace{
+				Id:       strings.ToLower(agency.Codespace),
+				Xmlns:    agency.Codespace,
+				XmlnsUrl: agency.AgencyURL,
+			},
+			Locale: locale{TimeZone: agency.Timezone, Language: agency.Lang},
+			Resource: resourceFrame{
+				entity: w.entity("ResourceFrame", 1),
+				Operator: operator{
+					entity: w.entity("Operator", 1),
+					Name:   agency.AgencyName,
+					Url:    agency.AgencyURL,
+				},
+			},
+			Site:          w.siteFrame(t),
+			Service:       w.serviceFrame(t),
+			Calendar:      w.calendarFrame(t, daily),
+			TimetableData: timetable,
+		},
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(delivery); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+func (w writer) siteFrame(t Timetable) siteFrame {
+	f := siteFrame{entity: w.entity("SiteFrame", 1), StopPlaces: make([]stopPlace, 0, len(t.Stations))}
+	for _, st := range t.Stations {
+		access := accessibility(st.Wheelchair)
+		place := stopPlace{
+			entity: w.entity("StopPlace", st.Id),
+			Name:   st.Name,
+			Accessibility: accessibilityAssessment{
+				entity:                 w.entity("AccessibilityAssessment", st.Id),
+				MobilityImpairedAccess: access,
+				WheelchairAccess:       access,
+			},
+			TransportMode: busMode,
+			Type:          "onstreetBus",
+		}
+		if st.Lat != nil && st.Lon != nil {
+			place.Centroid = &location{Longitude: *st.Lon, Latitude: *st.Lat}
+		}
+		f.StopPlaces = append(f.StopPlaces, place)
+	}
+	return f
+}
+
+func (w writer) serviceFrame(t Timetable) serviceFrame {
+	f := serviceFrame{entity: w.entity("ServiceFrame", 1)}
+	for i, st := range t.Stations {
+		f.RoutePoints = append(f.RoutePoints, routePoint{
+			entity: w.entity("RoutePoint", st.Id),
+			Projection: pointProjection{
+				entity:            w.entity("PointProjection", st.Id),
+				ProjectToPointRef: w.ref("ScheduledStopPoint", st.Id),
+			},
+		})
+		f.ScheduledStopPoints = append(f.ScheduledStopPoints, scheduledStopPoint{
+			entity: w.entity("ScheduledStopPoint", st.Id),
+			Name:   st.Name,
+		})
+		f.StopAssignments = append(f.StopAssignments, passengerStopAssignment{
+			entity:             w.entity("PassengerStopAssignment", st.Id),
+			Order:              i + 1,
+			ScheduledStopPoint: w.ref("ScheduledStopPoint", st.Id),
+			StopPlace:          w.ref("StopPlace", st.Id),
+		})
+	}
+
+	stations := make(map[int][]model.RouteStation)
+	for _, rs := range t.RouteStations {
+		stations[rs.RouteId] = append(stations[rs.RouteId], rs)
+	}
+	for _, r := range t.Routes {
+		f.Lines = append(f.Lines, line{
+			entity:        w.entity("Line", r.Id),
+			Name:          r.Name,
+			TransportMode: busMode,
+			PublicCode:    r.Name,
+			Operator:      w.ref("Operator", 1),
+		})
+		rt := route{entity: w.entity("Route", r.Id), Name: r.Name, Line: w.ref("Line", r.Id)}
+		pattern := serviceJourneyPattern{
+			entity: w.entity("ServiceJourneyPattern", r.Id),
+			Name:   r.Name,
+			Route:  w.ref("Route", r.Id),
+		}
+		for i, rs := range stations[r.Id] {
+			rt.Points = append(rt.Points, pointOnRoute{
+				entity:     w.entity("PointOnRoute", rs.Id),
+				Order:      i + 1,
+				RoutePoint: w.ref("RoutePoint", rs.StationId),
+			})
+			pattern.Points = append(pattern.Points, stopPointInJourneyPattern{
+				entity:             w.entity("StopPointInJourneyPattern", rs.Id),
+				Order:              i + 1,
+				ScheduledStopPoint: w.ref("ScheduledStopPoint", rs.StationId),
+			})
+		}
+		f.Routes = append(f.Routes, rt)
+		f.JourneyPatterns = append(f.JourneyPatterns, pattern)
+	}
+	return f
+}
+
+// timetableFrame also reports whether a trip runs without a calendar.
+func (w writer) timetableFrame(t Timetable) (timetableFrame, bool) {
+	f := timetableFrame{entity: w.entity("TimetableFrame", 1)}
+	daily := false
+	for start := 0; start < len(t.StopTimes); {
+		end := start + 1
+		for end < len(t.StopTimes) && t.StopTimes[end].TripId == t.StopTimes[start].TripId {
+			end++
+		}
+		stops := t.StopTimes[start:end]
+		start = end
+		if len(stops) < 2 {
+			continue
+		}
+
+		first := stops[0]
+		dayTypeRef := w.ref("DayType", dailyService)
+		if first.CalendarId != 0 {
+			dayTypeRef = w.ref("DayType", first.CalendarId)
+		} else {
+			daily = true
+		}
+		journey := serviceJourney{
+			entity:         w.entity("ServiceJourney", first.TripId),
+			DayTypes:       []ref{dayTypeRef},
+			JourneyPattern: w.ref("ServiceJourneyPattern", first.RouteId),
+			Operator:       w.ref("Operator", 1),
+		}
+		for i, st := range stops {
+			clock, offset := model.FormatClock(st.Time%day), st.Time/day
+			pt := timetabledPassingTime{
+				entity:    w.entity("TimetabledPassingTime", fmt.Sprintf("%d-%d", st.TripId, st.Pos)),
+				StopPoint: w.ref("StopPointInJourneyPattern", st.RouteStationId),
+			}
+			if i > 0 {
+				pt.ArrivalTime, pt.ArrivalDayOffset = clock, offset
+			}
+			if i < len(stops)-1 {
+				pt.DepartureTime, pt.DepartureDayOffset = clock, offset
+			}
+			journey.PassingTimes = append(journey.PassingTimes, pt)
+		}
+		f.Journeys = append(f.Journeys, journey)
+	}
+	return f, daily
+}
+
+func (w writer) calendarFrame(t Timetable, daily bool) serviceCalendarFrame {
+	f := serviceCalendarFrame{entity: w.entity("ServiceCalendarFrame", 1)}
+	if daily {
+		f.DayTypes = append(f.DayTypes, dayType{
+			entity:     w.entity("DayType", dailyService),
+			Name:       "Daily",
+			DaysOfWeek: "Everyday",
+		})
+	}
+
+	order := 0
+	for _, c := range t.Calendars {
+		f.DayTypes = append(f.DayTypes, dayType{
+			entity:     w.entity("DayType", c.Id),
+			Name:       c.Name,
+			DaysOfWeek: daysOfWeek(c),
+		})
+
+		from, errFrom := time.Parse(model.DateLayout, c.StartDate)
+		to, errTo := time.Parse(model.DateLayout, c.EndDate)
+		if errFrom == nil || errTo == nil {
+			if errFrom != nil {
+				from = startOfDay(t.Start)
+			}
+			if errTo != nil {
+				to = from.AddDate(1, 0, -1)
+			}
+			f.OperatingPeriods = append(f.OperatingPeriods, operatingPeriod{
+				entity:   w.entity("OperatingPeriod", c.Id),
+				FromDate: from.Format(dateLayout),
+				ToDate:   to.Format(dateLayout),
+			})
+			order++
+			period := w.ref("OperatingPeriod", c.Id)
+			f.DayTypeAssignments = append(f.DayTypeAssignments, dayTypeAssignment{
+				entity:          w.entity("DayTypeAssignment", c.Id),
+				Order:           order,
+				OperatingPeriod: &period,
+				DayType:         w.ref("DayType", c.Id),
+			})
+		}
+
+		for _, e := range c.Exceptions {
+			d, err := time.Parse(model.DateLayout, e.Date)
+			if err != nil {
+				continue
+			}
+			order++
+			available := e.Added
+			f.DayTypeAssignments = append(f.DayTypeAssignments, dayTypeAssignment{
+				entity:      w.entity("DayTypeAssignment", fmt.Sprintf("%d-%s", c.Id, d.Format("20060102"))),
+				Order:       order,
+				Date:        d.Format(dateLayout),
+				DayType:     w.ref("DayType", c.Id),
+				IsAvailable: &available,
+			})
+		}
+	}
+	return f
+}
+
+func daysOfWeek(c *model.Calendar) string {
+	days := make([]string, 0, 7)
+	for _, d := range []struct {
+		on   bool
+		name string
+	}{
+		{c.Monday, "Monday"}, {c.Tuesday, "Tuesday"}, {c.Wednesday, "Wednesday"}, {c.Thursday, "Thursday"},
+		{c.Friday, "Friday"}, {c.Saturday, "Saturday"}, {c.Sunday, "Sunday"},
+	} {
+		if d.on {
+			days = append(days, d.name)
+		}
+	}
+	switch len(days) {
+	case 0:
+		return "none"
+	case 7:
+		return "Everyday"
+	}
+	return strings.Join(days, " ")
+}
+
+func accessibility(w model.Wheelchair) string {
+	switch w {
+	case model.WheelchairAccessible:
+		return "true"
+	case model.WheelchairNotAccessible:
+		return "false"
+	}
+	return "unknown"
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
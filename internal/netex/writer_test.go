@@ -0,0 +1,140 @@
+package netex
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/config"
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+func writeDelivery(t *testing.T, tt Timetable) publicationDelivery {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Write(&buf, config.GTFS{Codespace: "BUS", Timezone: "Europe/Moscow"}, tt); err != nil {
+		t.Fatal(err)
+	}
+	var delivery publicationDelivery
+	if err := xml.Unmarshal(buf.Bytes(), &delivery); err != nil {
+		t.Fatalf("%v in\n%s", err, buf.String())
+	}
+	return delivery
+}
+
+func TestWrite(t *testing.T) {
+	stations := []*model.Station{{Id: 10, Name: "Central"}, {Id: 20, Name: "Market"}, {Id: 30, Name: "Dock"}}
+	routeStations := []model.RouteStation{
+		{Id: 51, RouteId: 5, StationId: 10, Pos: 0},
+		{Id: 52, RouteId: 5, StationId: 20, Pos: 1},
+		{Id: 53, RouteId: 5, StationId: 30, Pos: 2},
+	}
+	stop := func(tripId, calendarId int, rs model.RouteStation, clock string) model.StopTime {
+		sec, err := model.ParseClock(clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return model.StopTime{
+			RouteStationId: rs.Id, RouteId: rs.RouteId, StationId: rs.StationId, Pos: rs.Pos,
+			TripId: tripId, CalendarId: calendarId, Time: sec,
+		}
+	}
+	delivery := writeDelivery(t, Timetable{
+		Stations:      stations,
+		Routes:        []*model.Route{{Id: 5, Name: "5"}},
+		RouteStations: routeStations,
+		Calendars: []*model.Calendar{{
+			Id: 7, Name: "Weekdays", Monday: true, Tuesday: true, Wednesday: true, Thursday: true, Friday: true,
+			StartDate: "2025-03-01", EndDate: "2025-12-31",
+			Exceptions: []model.CalendarDate{{Date: "2025-05-01", Added: false}},
+		}},
+		StopTimes: []model.StopTime{
+			stop(100, 7, routeStations[0], "08:00"),
+			stop(100, 7, routeStations[1], "08:10"),
+			stop(100, 7, routeStations[2], "08:20"),
+			// runs past midnight every day
+			stop(101, 0, routeStations[0], "23:50"),
+			stop(101, 0, routeStations[1], "24:10"),
+			stop(101, 0, routeStations[2], "24:30"),
+		},
+		Start: time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC),
+	})
+	frame := delivery.Frame
+
+	ids := make(map[string]bool)
+	for _, p := range frame.Service.JourneyPatterns {
+		for _, sp := range p.Points {
+			ids[sp.Id] = true
+		}
+	}
+	for _, rt := range frame.Service.Routes {
+		ids[rt.Id] = true
+	}
+	for _, p := range frame.Service.ScheduledStopPoints {
+		ids[p.Id] = true
+	}
+	for _, d := range frame.Calendar.DayTypes {
+		ids[d.Id] = true
+	}
+	for _, p := range frame.Calendar.OperatingPeriods {
+		ids[p.Id] = true
+	}
+
+	if len(frame.Service.JourneyPatterns) != 1 {
+		t.Fatalf("got %d journey patterns, want 1", len(frame.Service.JourneyPatterns))
+	}
+	pattern := frame.Service.JourneyPatterns[0]
+	if pattern.Id != "BUS:ServiceJourneyPattern:5" || pattern.Route.Ref != "BUS:Route:5" || !ids[pattern.Route.Ref] {
+		t.Errorf("journey pattern %s on route %s", pattern.Id, pattern.Route.Ref)
+	}
+	for i, sp := range pattern.Points {
+		if sp.Order != i+1 || !ids[sp.ScheduledStopPoint.Ref] {
+			t.Errorf("stop point %s order %d at %s", sp.Id, sp.Order, sp.ScheduledStopPoint.Ref)
+		}
+	}
+
+	for _, a := range frame.Calendar.DayTypeAssignments {
+		if !ids[a.DayType.Ref] || a.OperatingPeriod != nil && !ids[a.OperatingPeriod.Ref] {
+			t.Errorf("day type assignment %s refers to a missing object", a.Id)
+		}
+	}
+	if got := len(frame.Calendar.DayTypeAssignments); got != 2 {
+		t.Errorf("got %d day type assignments, want the period and the exception", got)
+	}
+
+	journeys := frame.TimetableData.Journeys
+	if len(journeys) != 2 {
+		t.Fatalf("got %d service journeys, want 2", len(journeys))
+	}
+	dayTypes := map[string]string{"BUS:ServiceJourney:100": "BUS:DayType:7", "BUS:ServiceJourney:101": "BUS:DayType:daily"}
+	for _, j := range journeys {
+		if len(j.DayTypes) != 1 || j.DayTypes[0].Ref != dayTypes[j.Id] || !ids[j.DayTypes[0].Ref] {
+			t.Errorf("journey %s runs on %v, want %s", j.Id, j.DayTypes, dayTypes[j.Id])
+		}
+		if j.JourneyPattern.Ref != pattern.Id {
+			t.Errorf("journey %s follows %s", j.Id, j.JourneyPattern.Ref)
+		}
+		for _, pt := range j.PassingTimes {
+			if !ids[pt.StopPoint.Ref] {
+				t.Errorf("passing time %s at a missing stop point %s", pt.Id, pt.StopPoint.Ref)
+			}
+		}
+	}
+
+	overnight := journeys[1].PassingTimes
+	want := []timetabledPassingTime{
+		{DepartureTime: "23:50:00"},
+		{ArrivalTime: "00:10:00", ArrivalDayOffset: 1, DepartureTime: "00:10:00", DepartureDayOffset: 1},
+		{ArrivalTime: "00:30:00", ArrivalDayOffset: 1},
+	}
+	if len(overnight) != len(want) {
+		t.Fatalf("got %d passing times, want %d", len(overnight), len(want))
+	}
+	for i, pt := range overnight {
+		pt.entity, pt.StopPoint = entity{}, ref{}
+		if pt != want[i] {
+			t.Errorf("passing time %d is %+v, want %+v", i, pt, want[i])
+		}
+	}
+}
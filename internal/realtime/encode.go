@@ -0,0 +1,155 @@
+package realtime
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Encode writes the message in the binary protobuf format. Vehicle
+// positions are not published and are left out.
+func Encode(msg *FeedMessage) []byte {
+	var h []byte
+	h = appendString(h, 1, msg.Header.Version)
+	h = appendVarint(h, 2, uint64(msg.Header.Incrementality))
+	h = appendVarint(h, 3, msg.Header.Timestamp)
+
+	b := appendBytes(nil, 1, h)
+	for _, e := range msg.Entities {
+		b = appendBytes(b, 2, encodeEntity(e))
+	}
+	return b
+}
+
+func encodeEntity(e FeedEntity) []byte {
+	b := appendBytes(nil, 1, []byte(e.Id))
+	if e.IsDeleted {
+		b = appendVarint(b, 2, 1)
+	}
+	if e.TripUpdate != nil {
+		b = appendBytes(b, 3, encodeTripUpdate(e.TripUpdate))
+	}
+	if e.Alert != nil {
+		b = appendBytes(b, 5, encodeAlert(e.Alert))
+	}
+	return b
+}
+
+func encodeTrip(t TripDescriptor) []byte {
+	var b []byte
+	b = appendString(b, 1, t.TripId)
+	b = appendString(b, 2, t.StartTime)
+	b = appendString(b, 3, t.StartDate)
+	if t.ScheduleRelationship != ScheduleScheduled {
+		b = appendVarint(b, 4, uint64(t.ScheduleRelationship))
+	}
+	b = appendString(b, 5, t.RouteId)
+	return b
+}
+
+func encodeTripUpdate(u *TripUpdate) []byte {
+	b := appendBytes(nil, 1, encodeTrip(u.Trip))
+	for _, s := range u.StopTimeUpdates {
+		b = appendBytes(b, 2, encodeStopTimeUpdate(s))
+	}
+	if u.Timestamp != 0 {
+		b = appendVarint(b, 4, u.Timestamp)
+	}
+	if u.Delay != nil {
+		b = appendVarint(b, 5, uint64(int64(*u.Delay)))
+	}
+	return b
+}
+
+func encodeStopTimeUpdate(s StopTimeUpdate) []byte {
+	var b []byte
+	if s.StopSequence != nil {
+		b = appendVarint(b, 1, uint64(*s.StopSequence))
+	}
+	if s.Arrival != nil {
+		b = appendBytes(b, 2, encodeStopTimeEvent(s.Arrival))
+	}
+	if s.Departure != nil {
+		b = appendBytes(b, 3, encodeStopTimeEvent(s.Departure))
+	}
+	b = appendString(b, 4, s.StopId)
+	if s.ScheduleRelationship != ScheduleScheduled {
+		b = appendVarint(b, 5, uint64(s.ScheduleRelationship))
+	}
+	return b
+}
+
+func encodeStopTimeEvent(e *StopTimeEvent) []byte {
+	var b []byte
+	if e.Delay != nil {
+		// negative int32 values are sign extended to ten bytes
+		b = appendVarint(b, 1, uint64(int64(*e.Delay)))
+	}
+	if e.Time != nil {
+		b = appendVarint(b, 2, uint64(*e.Time))
+	}
+	return b
+}
+
+func encodeAlert(a *Alert) []byte {
+	var b []byte
+	for _, p := range a.ActivePeriods {
+		var r []byte
+		if p.Start != 0 {
+			r = appendVarint(r, 1, p.Start)
+		}
+		if p.End != 0 {
+			r = appendVarint(r, 2, p.End)
+		}
+		b = appendBytes(b, 1, r)
+	}
+	for _, e := range a.InformedEntities {
+		var s []byte
+		s = appendString(s, 2, e.RouteId)
+		if e.Trip != nil {
+			s = appendBytes(s, 4, encodeTrip(*e.Trip))
+		}
+		s = appendString(s, 5, e.StopId)
+		b = appendBytes(b, 5, s)
+	}
+	if a.Cause != 0 {
+		b = appendVarint(b, 6, uint64(a.Cause))
+	}
+	if a.Effect != 0 {
+		b = appendVarint(b, 7, uint64(a.Effect))
+	}
+	if len(a.HeaderText.Translations) > 0 {
+		b = appendBytes(b, 10, encodeTranslated(a.HeaderText))
+	}
+	if a.DescriptionText != nil {
+		b = appendBytes(b, 11, encodeTranslated(*a.DescriptionText))
+	}
+	return b
+}
+
+func encodeTranslated(s TranslatedString) []byte {
+	var b []byte
+	for _, t := range s.Translations {
+		var tb []byte
+		tb = appendBytes(tb, 1, []byte(t.Text))
+		tb = appendString(tb, 2, t.Language)
+		b = appendBytes(b, 1, tb)
+	}
+	return b
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+// appendString leaves out empty strings, they are optional fields.
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendBytes(b, num, []byte(s))
+}
@@ -0,0 +1,355 @@
+package realtime
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The types below hold the part of gtfs-realtime.proto the service reads
+// and publishes, they are coded field by field with protowire. Field
+// numbers follow the GTFS-realtime 2.0 spec, the JSON names follow the
+// proto field names.
+
+const (
+	FullDataset = 0
+
+	ScheduleScheduled = 0
+	ScheduleSkipped   = 1
+	ScheduleNoData    = 2
+	ScheduleCanceled  = 3
+
+	CauseUnknown = 1
+
+	EffectNoService         = 1
+	EffectSignificantDelays = 3
+	EffectModifiedService   = 6
+)
+
+type FeedMessage struct {
+	Header   FeedHeader   `json:"header"`
+	Entities []FeedEntity `json:"entity,omitempty"`
+}
+
+type FeedHeader struct {
+	Version        string `json:"gtfs_realtime_version,omitempty"`
+	Incrementality int    `json:"incrementality,omitempty"`
+	Timestamp      uint64 `json:"timestamp,omitempty"`
+}
+
+type FeedEntity struct {
+	Id         string           `json:"id"`
+	IsDeleted  bool             `json:"is_deleted,omitempty"`
+	TripUpdate *TripUpdate      `json:"trip_update,omitempty"`
+	Vehicle    *VehiclePosition `json:"vehicle,omitempty"`
+	Alert      *Alert           `json:"alert,omitempty"`
+}
+
+type TripDescriptor struct {
+	TripId               string `json:"trip_id,omitempty"`
+	RouteId              string `json:"route_id,omitempty"`
+	StartTime            string `json:"start_time,omitempty"`
+	StartDate            string `json:"start_date,omitempty"`
+	ScheduleRelationship int    `json:"schedule_relationship,omitempty"`
+}
+
+type TripUpdate struct {
+	Trip            TripDescriptor   `json:"trip"`
+	StopTimeUpdates []StopTimeUpdate `json:"stop_time_update,omitempty"`
+	Timestamp       uint64           `json:"timestamp,omitempty"`
+	Delay           *int32           `json:"delay,omitempty"`
+}
+
+type StopTimeUpdate struct {
+	StopSequence         *uint32        `json:"stop_sequence,omitempty"`
+	StopId               string         `json:"stop_id,omitempty"`
+	Arrival              *StopTimeEvent `json:"arrival,omitempty"`
+	Departure            *StopTimeEvent `json:"departure,omitempty"`
+	ScheduleRelationship int            `json:"schedule_relationship,omitempty"`
+}
+
+type StopTimeEvent struct {
+	Delay *int32 `json:"delay,omitempty"`
+	Time  *int64 `json:"time,omitempty"`
+}
+
+type VehiclePosition struct {
+	Trip                *TripDescriptor `json:"trip,omitempty"`
+	VehicleId           string          `json:"vehicle_id,omitempty"`
+	Position            *Position       `json:"position,omitempty"`
+	CurrentStopSequence *uint32         `json:"current_stop_sequence,omitempty"`
+	StopId              string          `json:"stop_id,omitempty"`
+	Timestamp           uint64          `json:"timestamp,omitempty"`
+}
+
+type Position struct {
+	Latitude  float32  `json:"latitude"`
+	Longitude float32  `json:"longitude"`
+	Bearing   *float32 `json:"bearing,omitempty"`
+	Speed     *float32 `json:"speed,omitempty"`
+}
+
+type Alert struct {
+	ActivePeriods    []TimeRange       `json:"active_period,omitempty"`
+	InformedEntities []EntitySelector  `json:"informed_entity"`
+	Cause            int               `json:"cause,omitempty"`
+	Effect           int               `json:"effect,omitempty"`
+	HeaderText       TranslatedString  `json:"header_text"`
+	DescriptionText  *TranslatedString `json:"description_text,omitempty"`
+}
+
+type TimeRange struct {
+	Start uint64 `json:"start,omitempty"`
+	End   uint64 `json:"end,omitempty"`
+}
+
+type EntitySelector struct {
+	RouteId string          `json:"route_id,omitempty"`
+	Trip    *TripDescriptor `json:"trip,omitempty"`
+	StopId  string          `json:"stop_id,omitempty"`
+}
+
+type TranslatedString struct {
+	Translations []Translation `json:"translation"`
+}
+
+type Translation struct {
+	Text     string `json:"text"`
+	Language string `json:"language,omitempty"`
+}
+
+// field is one decoded field, v holds varint and fixed values and data the
+// bytes of length delimited ones.
+type field struct {
+	num  protowire.Number
+	v    uint64
+	data []byte
+}
+
+func (f field) string() string { return string(f.data) }
+func (f field) int32() int32   { return int32(f.v) }
+func (f field) float32() float32 {
+	return math.Float32frombits(uint32(f.v))
+}
+
+// fields calls fn for every field of the message, unknown ones included.
+func fields(b []byte, fn func(f field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		f := field{num: num}
+		switch typ {
+		case protowire.VarintType:
+			f.v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			f.v = uint64(v)
+		case protowire.Fixed64Type:
+			f.v, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			f.data, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode reads a binary FeedMessage.
+func Decode(b []byte) (*FeedMessage, error) {
+	msg := &FeedMessage{}
+	err := fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return fields(f.data, func(f field) error {
+				switch f.num {
+				case 1:
+					msg.Header.Version = f.string()
+				case 2:
+					msg.Header.Incrementality = int(f.v)
+				case 3:
+					msg.Header.Timestamp = f.v
+				}
+				return nil
+			})
+		case 2:
+			e, err := decodeEntity(f.data)
+			if err != nil {
+				return err
+			}
+			msg.Entities = append(msg.Entities, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("wrong GTFS-realtime message: %w", err)
+	}
+	return msg, nil
+}
+
+func decodeEntity(b []byte) (FeedEntity, error) {
+	var e FeedEntity
+	err := fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			e.Id = f.string()
+		case 2:
+			e.IsDeleted = f.v != 0
+		case 3:
+			u, err := decodeTripUpdate(f.data)
+			e.TripUpdate = u
+			return err
+		case 4:
+			v, err := decodeVehicle(f.data)
+			e.Vehicle = v
+			return err
+		}
+		return nil
+	})
+	return e, err
+}
+
+func decodeTrip(b []byte) (TripDescriptor, error) {
+	var t TripDescriptor
+	err := fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			t.TripId = f.string()
+		case 2:
+			t.StartTime = f.string()
+		case 3:
+			t.StartDate = f.string()
+		case 4:
+			t.ScheduleRelationship = int(f.v)
+		case 5:
+			t.RouteId = f.string()
+		}
+		return nil
+	})
+	return t, err
+}
+
+func decodeTripUpdate(b []byte) (*TripUpdate, error) {
+	u := &TripUpdate{}
+	err := fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			t, err := decodeTrip(f.data)
+			u.Trip = t
+			return err
+		case 2:
+			s, err := decodeStopTimeUpdate(f.data)
+			u.StopTimeUpdates = append(u.StopTimeUpdates, s)
+			return err
+		case 4:
+			u.Timestamp = f.v
+		case 5:
+			d := f.int32()
+			u.Delay = &d
+		}
+		return nil
+	})
+	return u, err
+}
+
+func decodeStopTimeUpdate(b []byte) (StopTimeUpdate, error) {
+	var s StopTimeUpdate
+	err := fields(b, func(f field) error {
+		var err error
+		switch f.num {
+		case 1:
+			seq := uint32(f.v)
+			s.StopSequence = &seq
+		case 2:
+			s.Arrival, err = decodeStopTimeEvent(f.data)
+		case 3:
+			s.Departure, err = decodeStopTimeEvent(f.data)
+		case 4:
+			s.StopId = f.string()
+		case 5:
+			s.ScheduleRelationship = int(f.v)
+		}
+		return err
+	})
+	return s, err
+}
+
+func decodeStopTimeEvent(b []byte) (*StopTimeEvent, error) {
+	e := &StopTimeEvent{}
+	err := fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			d := f.int32()
+			e.Delay = &d
+		case 2:
+			t := int64(f.v)
+			e.Time = &t
+		}
+		return nil
+	})
+	return e, err
+}
+
+func decodeVehicle(b []byte) (*VehiclePosition, error) {
+	v := &VehiclePosition{}
+	err := fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			t, err := decodeTrip(f.data)
+			v.Trip = &t
+			return err
+		case 2:
+			p, err := decodePosition(f.data)
+			v.Position = p
+			return err
+		case 3:
+			seq := uint32(f.v)
+			v.CurrentStopSequence = &seq
+		case 5:
+			v.Timestamp = f.v
+		case 7:
+			v.StopId = f.string()
+		case 8:
+			return fields(f.data, func(f field) error {
+				if f.num == 1 {
+					v.VehicleId = f.string()
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return v, err
+}
+
+func decodePosition(b []byte) (*Position, error) {
+	p := &Position{}
+	err := fields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			p.Latitude = f.float32()
+		case 2:
+			p.Longitude = f.float32()
+		case 3:
+			bearing := f.float32()
+			p.Bearing = &bearing
+		case 5:
+			speed := f.float32()
+			p.Speed = &speed
+		}
+		return nil
+	})
+	return p, err
+}
@@ -0,0 +1,211 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/config"
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/alexeybs90/go_bus_routes/pkg/logger"
+)
+
+const gtfsDateLayout = "20060102"
+
+type Poller struct {
+	cfg    config.Realtime
+	loc    *time.Location
+	store  *Store
+	client *http.Client
+	logger logger.Logger
+}
+
+// NewPoller reads start dates of the feed as days in loc, the agency
+// timezone.
+func NewPoller(cfg config.Realtime, loc *time.Location, store *Store, log logger.Logger) (*Poller, error) {
+	if cfg.Interval <= 0 {
+		return nil, errors.New("realtime interval must be positive")
+	}
+	return &Poller{
+		cfg:    cfg,
+		loc:    loc,
+		store:  store,
+		client: &http.Client{Timeout: cfg.Interval},
+		logger: log,
+	}, nil
+}
+
+// Run polls the feed until ctx is done, a failed poll keeps the previous
+// state.
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Poll(ctx); err != nil {
+			p.logger.Error("realtime poll failed", slog.String("error", err.Error()))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Poller) Poll(ctx context.Context) error {
+	b, err := p.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	msg, err := Decode(b)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	trips, vehicles := convert(msg, now, p.loc)
+	p.store.Replace(trips, vehicles, now)
+	p.logger.Debug("realtime feed applied", slog.Int("trips", len(trips)), slog.Int("vehicles", len(vehicles)))
+	return nil
+}
+
+func (p *Poller) fetch(ctx context.Context) ([]byte, error) {
+	if p.cfg.URL == "" {
+		return os.ReadFile(p.cfg.File)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("realtime feed responded %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// convert maps the feed onto stored trips, the feed uses our trip and
+// station ids as published in the GTFS export. Entities for other trips
+// are ignored.
+func convert(msg *FeedMessage, now time.Time, loc *time.Location) (map[int]model.TripUpdate, []model.VehiclePosition) {
+	trips := make(map[int]model.TripUpdate)
+	vehicles := make([]model.VehiclePosition, 0)
+	for _, e := range msg.Entities {
+		if e.IsDeleted {
+			continue
+		}
+		if u := e.TripUpdate; u != nil {
+			if item, ok := convertTripUpdate(u, timestamp(u.Timestamp, msg.Header.Timestamp, now), loc); ok {
+				trips[item.TripId] = item
+			}
+		}
+		if v := e.Vehicle; v != nil && v.Trip != nil && v.Position != nil {
+			if item, ok := convertVehicle(v, timestamp(v.Timestamp, msg.Header.Timestamp, now)); ok {
+				vehicles = append(vehicles, item)
+			}
+		}
+	}
+	return trips, vehicles
+}
+
+func convertTripUpdate(u *TripUpdate, at time.Time, loc *time.Location) (model.TripUpdate, bool) {
+	tripId, err := strconv.Atoi(u.Trip.TripId)
+	if err != nil {
+		return model.TripUpdate{}, false
+	}
+	day := serviceDay(u.Trip.StartDate, at, loc)
+	item := model.TripUpdate{
+		TripId:    tripId,
+		StartDate: day.Format(model.DateLayout),
+		Canceled:  u.Trip.ScheduleRelationship == ScheduleCanceled,
+		Stops:     make([]model.StopUpdate, 0, len(u.StopTimeUpdates)),
+		Timestamp: at,
+	}
+	if u.Delay != nil {
+		delay := int(*u.Delay)
+		item.Delay = &delay
+	}
+
+	for _, s := range u.StopTimeUpdates {
+		if s.ScheduleRelationship == ScheduleNoData {
+			continue
+		}
+		stop := model.StopUpdate{Pos: -1, Skipped: s.ScheduleRelationship == ScheduleSkipped}
+		if s.StopSequence != nil {
+			stop.Pos = int(*s.StopSequence)
+		}
+		stop.StationId, _ = strconv.Atoi(s.StopId)
+		if stop.Pos < 0 && stop.StationId == 0 {
+			continue
+		}
+		event := s.Departure
+		if event == nil {
+			event = s.Arrival
+		}
+		if event != nil && event.Time != nil {
+			t := int(*event.Time - day.Unix())
+			stop.Time = &t
+		} else if event != nil && event.Delay != nil {
+			delay := int(*event.Delay)
+			stop.Delay = &delay
+		} else if !stop.Skipped {
+			continue
+		}
+		item.Stops = append(item.Stops, stop)
+	}
+	return item, true
+}
+
+func convertVehicle(v *VehiclePosition, at time.Time) (model.VehiclePosition, bool) {
+	tripId, err := strconv.Atoi(v.Trip.TripId)
+	if err != nil {
+		return model.VehiclePosition{}, false
+	}
+	item := model.VehiclePosition{
+		TripId:    tripId,
+		VehicleId: v.VehicleId,
+		Lat:       float64(v.Position.Latitude),
+		Lon:       float64(v.Position.Longitude),
+		Timestamp: at,
+	}
+	if v.Position.Bearing != nil {
+		bearing := float64(*v.Position.Bearing)
+		item.Bearing = &bearing
+	}
+	if v.CurrentStopSequence != nil {
+		pos := int(*v.CurrentStopSequence)
+		item.Pos = &pos
+	}
+	item.StationId, _ = strconv.Atoi(v.StopId)
+	return item, true
+}
+
+// serviceDay is the midnight in loc of the trip start date, the day of at
+// when the feed leaves the date out.
+func serviceDay(startDate string, at time.Time, loc *time.Location) time.Time {
+	if d, err := time.ParseInLocation(gtfsDateLayout, startDate, loc); err == nil {
+		return d
+	}
+	at = at.In(loc)
+	return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, loc)
+}
+
+func timestamp(entity, header uint64, now time.Time) time.Time {
+	switch {
+	case entity != 0:
+		return time.Unix(int64(entity), 0)
+	case header != 0:
+		return time.Unix(int64(header), 0)
+	default:
+		return now
+	}
+}
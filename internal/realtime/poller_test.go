@@ -0,0 +1,130 @@
+package realtime
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/config"
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// agency is ten hours ahead of UTC, so its service day differs from the
+// UTC one in the evening.
+var agency = time.FixedZone("agency", 10*3600)
+
+func ptr[T any](v T) *T { return &v }
+
+func testPoller(t *testing.T, handler http.HandlerFunc) (*Poller, *Store) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	store := NewStore()
+	p, err := NewPoller(config.Realtime{URL: srv.URL, Interval: time.Second}, agency, store,
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, store
+}
+
+func serveFeed(msg *FeedMessage) http.HandlerFunc {
+	b := Encode(msg)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write(b)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	midnight := time.Date(2025, 3, 4, 0, 0, 0, 0, agency)
+	// 20:00 UTC on March 4 is already March 5 for the agency
+	header := uint64(time.Date(2025, 3, 4, 20, 0, 0, 0, time.UTC).Unix())
+	p, store := testPoller(t, serveFeed(&FeedMessage{
+		Header: FeedHeader{Version: "2.0", Timestamp: header},
+		Entities: []FeedEntity{
+			{Id: "late", TripUpdate: &TripUpdate{
+				Trip:  TripDescriptor{TripId: "101", StartDate: "20250304"},
+				Delay: ptr(int32(-30)),
+				StopTimeUpdates: []StopTimeUpdate{
+					{StopSequence: ptr(uint32(1)), Arrival: &StopTimeEvent{Delay: ptr(int32(120))}},
+					{StopSequence: ptr(uint32(3)), Departure: &StopTimeEvent{Time: ptr(midnight.Add(8*time.Hour + 30*time.Minute).Unix())}},
+					{StopId: "7", ScheduleRelationship: ScheduleSkipped},
+					{StopSequence: ptr(uint32(4)), ScheduleRelationship: ScheduleNoData},
+				},
+			}},
+			{Id: "canceled", TripUpdate: &TripUpdate{
+				Trip: TripDescriptor{TripId: "102", ScheduleRelationship: ScheduleCanceled},
+			}},
+			{Id: "other", TripUpdate: &TripUpdate{Trip: TripDescriptor{TripId: "feed-only"}}},
+		},
+	}))
+	if err := p.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	trips := store.TripUpdates()
+	if len(trips) != 2 {
+		t.Fatalf("got %d trip updates, want 2", len(trips))
+	}
+
+	late := trips[101]
+	if late.StartDate != "2025-03-04" || late.Delay == nil || *late.Delay != -30 {
+		t.Errorf("trip 101 on %s with delay %v", late.StartDate, late.Delay)
+	}
+	if len(late.Stops) != 3 {
+		t.Fatalf("got %d stop updates, want 3", len(late.Stops))
+	}
+	if s := late.Stops[0]; s.Pos != 1 || s.Delay == nil || *s.Delay != 120 {
+		t.Errorf("first stop update %+v", s)
+	}
+	if s := late.Stops[1]; s.Pos != 3 || s.Time == nil || model.FormatClock(*s.Time) != "08:30:00" {
+		t.Errorf("second stop update %+v", s)
+	}
+	if s := late.Stops[2]; s.Pos != -1 || s.StationId != 7 || !s.Skipped {
+		t.Errorf("third stop update %+v", s)
+	}
+
+	canceled := trips[102]
+	if !canceled.Canceled || canceled.StartDate != "2025-03-05" {
+		t.Errorf("trip 102 canceled %v on %s, want canceled on the agency day 2025-03-05", canceled.Canceled, canceled.StartDate)
+	}
+}
+
+func TestPollKeepsStateOnError(t *testing.T) {
+	var fail atomic.Bool
+	feed := serveFeed(&FeedMessage{
+		Header:   FeedHeader{Version: "2.0"},
+		Entities: []FeedEntity{{Id: "e", TripUpdate: &TripUpdate{Trip: TripDescriptor{TripId: "101"}}}},
+	})
+	p, store := testPoller(t, func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		feed(w, r)
+	})
+	if err := p.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	fail.Store(true)
+	if err := p.Poll(context.Background()); err == nil {
+		t.Fatal("want an error for a failed request")
+	}
+	if _, ok := store.TripUpdates()[101]; !ok {
+		t.Error("failed poll dropped the previous state")
+	}
+}
+
+func TestNewPollerInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := NewPoller(config.Realtime{File: "feed.pb", Interval: interval}, agency, NewStore(), nil); err == nil {
+			t.Errorf("interval %v accepted", interval)
+		}
+	}
+}
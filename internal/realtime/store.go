@@ -0,0 +1,51 @@
+package realtime
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// Store keeps the last realtime state. Every poll replaces the maps as a
+// whole, so the returned maps and slices are never changed afterwards.
+type Store struct {
+	mu       sync.RWMutex
+	trips    map[int]model.TripUpdate
+	vehicles []model.VehiclePosition
+	updated  time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		trips:    make(map[int]model.TripUpdate),
+		vehicles: make([]model.VehiclePosition, 0),
+	}
+}
+
+func (s *Store) Replace(trips map[int]model.TripUpdate, vehicles []model.VehiclePosition, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trips = trips
+	s.vehicles = vehicles
+	s.updated = at
+}
+
+// TripUpdates is keyed by trip id.
+func (s *Store) TripUpdates() map[int]model.TripUpdate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.trips
+}
+
+func (s *Store) Vehicles() []model.VehiclePosition {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vehicles
+}
+
+func (s *Store) Updated() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.updated
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// foreignKeyViolation is the SQLSTATE of a delete blocked by ON DELETE RESTRICT
+const foreignKeyViolation = "23503"
+
+const calendarColumns = `c.id, c.name, c.monday, c.tuesday, c.wednesday, c.thursday, c.friday, c.saturday, c.sunday,
+		COALESCE(c.start_date::text, ''), COALESCE(c.end_date::text, '')`
+
+func scanCalendar(row pgx.Row, item *model.Calendar) error {
+	return row.Scan(&item.Id, &item.Name, &item.Monday, &item.Tuesday, &item.Wednesday, &item.Thursday,
+		&item.Friday, &item.Saturday, &item.Sunday, &item.StartDate, &item.EndDate)
+}
+
+func (r *repository) GetCalendars(ctx context.Context) ([]model.Model, error) {
+	sql := "SELECT " + calendarColumns + " FROM calendar c ORDER BY c.name"
+	rows, err := r.client.Query(ctx, sql)
+	if err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	calendars := make([]*model.Calendar, 0)
+	byId := make(map[int]*model.Calendar)
+	for rows.Next() {
+		item := &model.Calendar{Exceptions: []model.CalendarDate{}}
+		if err = scanCalendar(rows, item); err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+		calendars = append(calendars, item)
+		byId[item.Id] = item
+	}
+
+	if err = r.loadCalendarDates(ctx, byId); err != nil {
+		return nil, err
+	}
+
+	items := make([]model.Model, 0, len(calendars))
+	for _, item := range calendars {
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (r *repository) GetCalendar(ctx context.Context, id int) (model.Model, error) {
+	item := &model.Calendar{Exceptions: []model.CalendarDate{}}
+	sql := "SELECT " + calendarColumns + " FROM calendar c WHERE c.id=$1"
+	if err := scanCalendar(r.client.QueryRow(ctx, sql, id), item); err != nil {
+		r.LogDB(err)
+		return item, err
+	}
+	if err := r.loadCalendarDates(ctx, map[int]*model.Calendar{item.Id: item}); err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+func (r *repository) loadCalendarDates(ctx context.Context, byId map[int]*model.Calendar) error {
+	sqlDates := "SELECT calendar_id, date::text, added FROM calendar_date ORDER BY date"
+	rows, err := r.client.Query(ctx, sqlDates)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var calendarId int
+		var d model.CalendarDate
+		if err = rows.Scan(&calendarId, &d.Date, &d.Added); err != nil {
+			r.LogDB(err)
+			return err
+		}
+		if c, ok := byId[calendarId]; ok {
+			c.Exceptions = append(c.Exceptions, d)
+		}
+	}
+	return nil
+}
+
+func (r *repository) saveCalendar(ctx context.Context, item *model.Calendar) error {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"id":        item.Id,
+		"name":      item.Name,
+		"monday":    item.Monday,
+		"tuesday":   item.Tuesday,
+		"wednesday": item.Wednesday,
+		"thursday":  item.Thursday,
+		"friday":    item.Friday,
+		"saturday":  item.Saturday,
+		"sunday":    item.Sunday,
+		"startDate": item.StartDate,
+		"endDate":   item.EndDate,
+	}
+	if item.Id == 0 {
+		sql := `INSERT INTO calendar (name, monday, tuesday, wednesday, thursday, friday, saturday, sunday, start_date, end_date)
+			VALUES (@name, @monday, @tuesday, @wednesday, @thursday, @friday, @saturday, @sunday,
+				NULLIF(@startDate, '')::date, NULLIF(@endDate, '')::date)
+			RETURNING id`
+		if err = tx.QueryRow(ctx, sql, args).Scan(&item.Id); err != nil {
+			r.LogDB(err)
+			return err
+		}
+	} else {
+		sql := `UPDATE calendar SET name=@name, monday=@monday, tuesday=@tuesday, wednesday=@wednesday,
+				thursday=@thursday, friday=@friday, saturday=@saturday, sunday=@sunday,
+				start_date=NULLIF(@startDate, '')::date, end_date=NULLIF(@endDate, '')::date
+			WHERE id=@id`
+		if _, err = tx.Exec(ctx, sql, args); err != nil {
+			r.LogDB(err)
+			return err
+		}
+		if _, err = tx.Exec(ctx, "DELETE FROM calendar_date WHERE calendar_id=$1", item.Id); err != nil {
+			r.LogDB(err)
+			return err
+		}
+	}
+
+	for _, d := range item.Exceptions {
+		sql := "INSERT INTO calendar_date (calendar_id, date, added) VALUES ($1, $2::date, $3)"
+		if _, err = tx.Exec(ctx, sql, item.Id, d.Date, d.Added); err != nil {
+			r.LogDB(err)
+			return err
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		r.LogDB(err)
+		return err
+	}
+	return nil
+}
+
+// deleteCalendar refuses to delete a calendar trips or frequencies still run
+// on, they would silently start running every day.
+func (r *repository) deleteCalendar(ctx context.Context, id int) error {
+	_, err := r.client.Exec(ctx, "DELETE FROM calendar WHERE id=$1", id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return fmt.Errorf("calendar %d is used by trips or frequencies, move them to another calendar first", id)
+	}
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *repository) GetFareZone(ctx context.Context, id int) (model.Model, error) {
+	var item model.FareZone
+	sql := "SELECT id, name FROM fare_zone WHERE id=$1"
+	if err := r.client.QueryRow(ctx, sql, id).Scan(&item.Id, &item.Name); err != nil {
+		r.LogDB(err)
+		return &item, err
+	}
+	return &item, nil
+}
+
+func (r *repository) GetFareZones(ctx context.Context) ([]model.Model, error) {
+	sql := "SELECT id, name FROM fare_zone ORDER BY name"
+	rows, err := r.client.Query(ctx, sql)
+	if err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.Model, 0)
+	for rows.Next() {
+		var item model.FareZone
+		if err = rows.Scan(&item.Id, &item.Name); err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+
+	return items, nil
+}
+
+func (r *repository) GetZonePrices(ctx context.Context) ([]model.ZonePrice, error) {
+	sql := "SELECT from_zone_id, to_zone_id, price FROM fare_zone_price ORDER BY from_zone_id, to_zone_id"
+	rows, err := r.client.Query(ctx, sql)
+	if err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.ZonePrice, 0)
+	for rows.Next() {
+		var item model.ZonePrice
+		if err = rows.Scan(&item.FromZoneId, &item.ToZoneId, &item.Price); err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// SaveZonePrice creates the price or replaces the existing one for the
+// same pair of zones.
+func (r *repository) SaveZonePrice(ctx context.Context, item model.ZonePrice) error {
+	if item.Price < 0 {
+		return fmt.Errorf("wrong price: %d", item.Price)
+	}
+	sql := `INSERT INTO fare_zone_price (from_zone_id, to_zone_id, price)
+		VALUES (@fromZoneId, @toZoneId, @price)
+		ON CONFLICT (from_zone_id, to_zone_id) DO UPDATE SET price=EXCLUDED.price`
+	args := pgx.NamedArgs{
+		"fromZoneId": item.FromZoneId,
+		"toZoneId":   item.ToZoneId,
+		"price":      item.Price,
+	}
+	if _, err := r.client.Exec(ctx, sql, args); err != nil {
+		r.LogDB(err)
+		return err
+	}
+	return nil
+}
+
+func (r *repository) DeleteZonePrice(ctx context.Context, fromZoneId int, toZoneId int) error {
+	sql := "DELETE FROM fare_zone_price WHERE from_zone_id=$1 AND to_zone_id=$2"
+	tag, err := r.client.Exec(ctx, sql, fromZoneId, toZoneId)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("no price from zone %d to zone %d", fromZoneId, toZoneId)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ImportFeed stores the whole feed in one transaction. A dry run does every
+// insert and rolls them back, so constraint errors are still reported.
+func (r *repository) ImportFeed(ctx context.Context, feed *model.Feed, opts model.ImportOptions) error {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if opts.Replace {
+		if _, err = tx.Exec(ctx, "TRUNCATE station, route, calendar CASCADE"); err != nil {
+			r.LogDB(err)
+			return err
+		}
+	}
+
+	stationArgs := make([][]any, len(feed.Stations))
+	for i, st := range feed.Stations {
+		stationArgs[i] = []any{st.Name, st.Lat, st.Lon, int(st.Wheelchair)}
+	}
+	stationIds, err := r.insertReturningIds(ctx, tx,
+		"INSERT INTO station (name, lat, lon, wheelchair) VALUES ($1, $2, $3, $4) RETURNING id", stationArgs)
+	if err != nil {
+		return err
+	}
+
+	calendarIds, err := r.importCalendars(ctx, tx, feed.Calendars)
+	if err != nil {
+		return err
+	}
+
+	routeArgs := make([][]any, len(feed.Routes))
+	for i, route := range feed.Routes {
+		routeArgs[i] = []any{route.Name}
+	}
+	routeIds, err := r.insertReturningIds(ctx, tx, "INSERT INTO route (name) VALUES ($1) RETURNING id", routeArgs)
+	if err != nil {
+		return err
+	}
+
+	var stopTimes [][]any
+	for i, route := range feed.Routes {
+		args := make([][]any, len(route.Stations))
+		for pos, station := range route.Stations {
+			args[pos] = []any{routeIds[i], stationIds[station], pos}
+		}
+		routeStationIds, err := r.insertReturningIds(ctx, tx,
+			"INSERT INTO route_stations (route_id, station_id, pos) VALUES ($1, $2, $3) RETURNING id", args)
+		if err != nil {
+			return err
+		}
+
+		args = make([][]any, len(route.Trips))
+		for queue, trip := range route.Trips {
+			args[queue] = []any{routeIds[i], queue, calendarIds[trip.Calendar], trip.Headsign, int(trip.Wheelchair)}
+		}
+		tripIds, err := r.insertReturningIds(ctx, tx,
+			`INSERT INTO trip (route_id, queue, calendar_id, headsign, wheelchair)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5) RETURNING id`, args)
+		if err != nil {
+			return err
+		}
+
+		for j, trip := range route.Trips {
+			for pos, t := range trip.Times {
+				interval := pgtype.Interval{Microseconds: int64(t) * int64(time.Second/time.Microsecond), Valid: true}
+				stopTimes = append(stopTimes, []any{routeStationIds[pos], tripIds[j], interval})
+			}
+		}
+	}
+
+	columns := []string{"route_station_id", "trip_id", "stop_time"}
+	if _, err = tx.CopyFrom(ctx, pgx.Identifier{"route_stations_time"}, columns, pgx.CopyFromRows(stopTimes)); err != nil {
+		r.LogDB(err)
+		return err
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+	if err = tx.Commit(ctx); err != nil {
+		r.LogDB(err)
+		return err
+	}
+	return nil
+}
+
+func (r *repository) importCalendars(ctx context.Context, tx pgx.Tx, calendars []model.Calendar) ([]int, error) {
+	args := make([][]any, len(calendars))
+	for i, c := range calendars {
+		args[i] = []any{c.Name, c.Monday, c.Tuesday, c.Wednesday, c.Thursday, c.Friday, c.Saturday, c.Sunday,
+			c.StartDate, c.EndDate}
+	}
+	ids, err := r.insertReturningIds(ctx, tx,
+		`INSERT INTO calendar (name, monday, tuesday, wednesday, thursday, friday, saturday, sunday, start_date, end_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, '')::date, NULLIF($10, '')::date)
+		RETURNING id`, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var dates [][]any
+	for i, c := range calendars {
+		for _, d := range c.Exceptions {
+			date, err := time.Parse(model.DateLayout, d.Date)
+			if err != nil {
+				return nil, err
+			}
+			dates = append(dates, []any{ids[i], date, d.Added})
+		}
+	}
+	columns := []string{"calendar_id", "date", "added"}
+	if _, err = tx.CopyFrom(ctx, pgx.Identifier{"calendar_date"}, columns, pgx.CopyFromRows(dates)); err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// insertReturningIds runs the insert once for every args row in a single
+// batch and returns the new ids in the same order.
+func (r *repository) insertReturningIds(ctx context.Context, tx pgx.Tx, sql string, args [][]any) ([]int, error) {
+	batch := &pgx.Batch{}
+	for _, a := range args {
+		batch.Queue(sql, a...)
+	}
+	results := tx.SendBatch(ctx, batch)
+	defer results.Close()
+
+	ids := make([]int, len(args))
+	for i := range args {
+		if err := results.QueryRow().Scan(&ids[i]); err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+	}
+	return ids, nil
+}
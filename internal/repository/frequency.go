@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetFrequencies returns the frequencies of the route, or of every route
+// when routeId is 0.
+func (r *repository) GetFrequencies(ctx context.Context, routeId int) ([]model.Frequency, error) {
+	sql := `SELECT f.id, f.route_id, r.name, f.calendar_id,
+			EXTRACT(EPOCH FROM f.start_time)::int, EXTRACT(EPOCH FROM f.end_time)::int, f.headway, f.wheelchair
+		FROM route_frequency f
+		JOIN route r ON r.id=f.route_id
+		WHERE @routeId=0 OR f.route_id=@routeId
+		ORDER BY f.route_id, f.start_time`
+	args := pgx.NamedArgs{"routeId": routeId}
+	rows, err := r.client.Query(ctx, sql, args)
+	if err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.Frequency, 0)
+	byId := make(map[int]int)
+	for rows.Next() {
+		var item model.Frequency
+		var start, end int
+		err = rows.Scan(&item.Id, &item.RouteId, &item.RouteName, &item.CalendarId, &start, &end, &item.Headway,
+			(*int)(&item.Wheelchair))
+		if err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+		item.StartTime = model.FormatClock(start)
+		item.EndTime = model.FormatClock(end)
+		item.Offsets = []model.FrequencyOffset{}
+		byId[item.Id] = len(items)
+		items = append(items, item)
+	}
+
+	sqlOffsets := `SELECT o.frequency_id, rs.id, s.id, s.name, rs.pos, o.minutes
+		FROM route_frequency_offset o
+		JOIN route_frequency f ON f.id=o.frequency_id
+		JOIN route_stations rs ON rs.id=o.route_station_id
+		JOIN station s ON s.id=rs.station_id
+		WHERE @routeId=0 OR f.route_id=@routeId
+		ORDER BY rs.pos`
+	rowsOffsets, err := r.client.Query(ctx, sqlOffsets, args)
+	if err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	defer rowsOffsets.Close()
+	for rowsOffsets.Next() {
+		var frequencyId int
+		var o model.FrequencyOffset
+		err = rowsOffsets.Scan(&frequencyId, &o.RouteStationId, &o.StationId, &o.StationName, &o.Pos, &o.Minutes)
+		if err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+		if i, ok := byId[frequencyId]; ok {
+			items[i].Offsets = append(items[i].Offsets, o)
+		}
+	}
+
+	return items, nil
+}
+
+func (r *repository) CreateFrequency(ctx context.Context, item *model.Frequency) error {
+	return r.saveFrequency(ctx, item)
+}
+
+func (r *repository) UpdateFrequency(ctx context.Context, item *model.Frequency) error {
+	if item.Id == 0 {
+		return errors.New("frequency id is required")
+	}
+	return r.saveFrequency(ctx, item)
+}
+
+func (r *repository) DeleteFrequency(ctx context.Context, routeId int, id int) error {
+	sql := "DELETE FROM route_frequency WHERE id=$1 AND route_id=$2"
+	tag, err := r.client.Exec(ctx, sql, id, routeId)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("frequency %d not found on route %d", id, routeId)
+	}
+	return nil
+}
+
+func (r *repository) saveFrequency(ctx context.Context, item *model.Frequency) error {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"id":         item.Id,
+		"routeId":    item.RouteId,
+		"calendarId": item.CalendarId,
+		"startTime":  item.StartTime,
+		"endTime":    item.EndTime,
+		"headway":    item.Headway,
+		"wheelchair": int(item.Wheelchair),
+	}
+	if item.Id == 0 {
+		sql := `INSERT INTO route_frequency (route_id, calendar_id, start_time, end_time, headway, wheelchair)
+			VALUES (@routeId, @calendarId, @startTime::interval, @endTime::interval, @headway, @wheelchair)
+			RETURNING id`
+		if err = tx.QueryRow(ctx, sql, args).Scan(&item.Id); err != nil {
+			r.LogDB(err)
+			return err
+		}
+	} else {
+		sql := `UPDATE route_frequency SET calendar_id=@calendarId, start_time=@startTime::interval,
+				end_time=@endTime::interval, headway=@headway, wheelchair=@wheelchair
+			WHERE id=@id AND route_id=@routeId`
+		tag, err := tx.Exec(ctx, sql, args)
+		if err != nil {
+			r.LogDB(err)
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("frequency %d not found on route %d", item.Id, item.RouteId)
+		}
+		if _, err = tx.Exec(ctx, "DELETE FROM route_frequency_offset WHERE frequency_id=$1", item.Id); err != nil {
+			r.LogDB(err)
+			return err
+		}
+	}
+
+	sql := `WITH ins AS (
+			INSERT INTO route_frequency_offset (frequency_id, route_station_id, minutes)
+			SELECT @frequencyId, rs.id, @minutes
+			FROM route_stations rs
+			WHERE rs.route_id=@routeId AND rs.station_id=@stationId
+			RETURNING route_station_id
+		)
+		SELECT rs.id, rs.pos, s.name
+		FROM ins
+		JOIN route_stations rs ON rs.id=ins.route_station_id
+		JOIN station s ON s.id=rs.station_id`
+	for i, o := range item.Offsets {
+		args := pgx.NamedArgs{
+			"frequencyId": item.Id,
+			"routeId":     item.RouteId,
+			"stationId":   o.StationId,
+			"minutes":     o.Minutes,
+		}
+		err = tx.QueryRow(ctx, sql, args).Scan(&item.Offsets[i].RouteStationId, &item.Offsets[i].Pos, &item.Offsets[i].StationName)
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("station %d is not on route %d", o.StationId, item.RouteId)
+		}
+		if err != nil {
+			r.LogDB(err)
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.LogDB(err)
+		return err
+	}
+	return nil
+}
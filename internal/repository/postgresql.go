@@ -2,12 +2,12 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/alexeybs90/go_bus_routes/internal/model"
 	"github.com/alexeybs90/go_bus_routes/pkg/logger"
 	"github.com/alexeybs90/go_bus_routes/pkg/storage/postgresql"
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,6 +17,17 @@ type repository struct {
 }
 
 func (r *repository) Create(ctx context.Context, item model.Model) error {
+	switch v := item.(type) {
+	case *model.Calendar:
+		v.Id = 0
+		return r.saveCalendar(ctx, v)
+	case *model.Station:
+		v.Id = 0
+		return r.saveStation(ctx, v)
+	case *model.Route:
+		v.Id = 0
+		return r.saveRoute(ctx, v)
+	}
 	sql := fmt.Sprintf("INSERT INTO %s (name) VALUES ($1) RETURNING id", item.DBTable())
 	var id int
 	if err := r.client.QueryRow(ctx, sql, item.GetName()).Scan(&id); err != nil {
@@ -28,6 +39,9 @@ func (r *repository) Create(ctx context.Context, item model.Model) error {
 }
 
 func (r *repository) Delete(ctx context.Context, item model.Model) error {
+	if v, ok := item.(*model.Calendar); ok {
+		return r.deleteCalendar(ctx, v.Id)
+	}
 	sql := fmt.Sprintf("DELETE FROM %s WHERE id=$1", item.DBTable())
 	_, err := r.client.Query(ctx, sql, item.GetID())
 	if err != nil {
@@ -38,7 +52,8 @@ func (r *repository) Delete(ctx context.Context, item model.Model) error {
 }
 
 func (r *repository) GetRoutes(ctx context.Context) ([]model.Model, error) {
-	sqlStation := `SELECT s.id, s.name, array_remove(array_agg(t.stop_time), NULL) AS stop_time, rs.route_id
+	sqlStation := `SELECT s.id, s.name, array_remove(array_agg(to_char(t.stop_time, 'HH24:MI:SS') ORDER BY t.stop_time), NULL) AS stop_time,
+			s.lat, s.lon, s.zone_id, s.wheelchair, rs.route_id
 		FROM route_stations rs
 		JOIN station s ON s.id=rs.station_id
 		LEFT JOIN route_stations_time t ON t.route_station_id=rs.id
@@ -50,35 +65,46 @@ func (r *repository) GetRoutes(ctx context.Context) ([]model.Model, error) {
 		return nil, err
 	}
 	defer rowsStation.Close()
+	stationTranslations, err := r.GetTranslations(ctx, "station")
+	if err != nil {
+		return nil, err
+	}
 	stationsByRouteId := make(map[int][]model.Station, 0)
 	for rowsStation.Next() {
 		var st model.Station
 		var routeId int
-		err = rowsStation.Scan(&st.Id, &st.Name, &st.StopTime, &routeId)
+		err = rowsStation.Scan(&st.Id, &st.Name, &st.StopTime, &st.Lat, &st.Lon, &st.ZoneId,
+			(*int)(&st.Wheelchair), &routeId)
 		if err != nil {
 			r.LogDB(err)
 			return nil, err
 		}
+		st.Translations = stationTranslations[st.Id]
 		stationsByRouteId[routeId] = append(stationsByRouteId[routeId], st)
 	}
 
-	sql := "SELECT id, name FROM route ORDER BY name"
+	sql := "SELECT id, name, price_per_km FROM route ORDER BY name"
 	rows, err := r.client.Query(ctx, sql)
 	if err != nil {
 		r.LogDB(err)
 		return nil, err
 	}
 	defer rows.Close()
+	routeTranslations, err := r.GetTranslations(ctx, "route")
+	if err != nil {
+		return nil, err
+	}
 
 	routes := make([]model.Model, 0)
 
 	for rows.Next() {
 		var rt model.Route
-		err = rows.Scan(&rt.Id, &rt.Name)
+		err = rows.Scan(&rt.Id, &rt.Name, &rt.PricePerKm)
 		if err != nil {
 			r.LogDB(err)
 			return nil, err
 		}
+		rt.Translations = routeTranslations[rt.Id]
 		rt.Stations = []model.Station{}
 		if arr, ok := stationsByRouteId[rt.Id]; ok {
 			rt.Stations = arr
@@ -91,13 +117,14 @@ func (r *repository) GetRoutes(ctx context.Context) ([]model.Model, error) {
 
 func (r *repository) GetRoute(ctx context.Context, id int) (model.Model, error) {
 	var item model.Route
-	sql := "SELECT id, name FROM route WHERE id=$1"
-	if err := r.client.QueryRow(ctx, sql, id).Scan(&item.Id, &item.Name); err != nil {
+	sql := "SELECT id, name, price_per_km FROM route WHERE id=$1"
+	if err := r.client.QueryRow(ctx, sql, id).Scan(&item.Id, &item.Name, &item.PricePerKm); err != nil {
 		r.LogDB(err)
 		return &item, err
 	}
 
-	sqlStation := `SELECT s.id, s.name, array_remove(array_agg(t.stop_time), NULL) AS stop_time
+	sqlStation := `SELECT s.id, s.name, array_remove(array_agg(to_char(t.stop_time, 'HH24:MI:SS') ORDER BY t.stop_time), NULL) AS stop_time,
+			s.lat, s.lon, s.zone_id, s.wheelchair
 		FROM route_stations rs
 		INNER JOIN station s ON s.id=rs.station_id
 		LEFT JOIN route_stations_time t ON t.route_station_id=rs.id
@@ -110,14 +137,25 @@ func (r *repository) GetRoute(ctx context.Context, id int) (model.Model, error)
 		return &item, err
 	}
 	defer rowsStation.Close()
+	stationTranslations, err := r.GetTranslations(ctx, "station")
+	if err != nil {
+		return &item, err
+	}
+	routeTranslations, err := r.GetTranslations(ctx, "route")
+	if err != nil {
+		return &item, err
+	}
+	item.Translations = routeTranslations[item.Id]
 	item.Stations = []model.Station{}
 	for rowsStation.Next() {
 		var st model.Station
-		err = rowsStation.Scan(&st.Id, &st.Name, &st.StopTime)
+		err = rowsStation.Scan(&st.Id, &st.Name, &st.StopTime, &st.Lat, &st.Lon, &st.ZoneId,
+			(*int)(&st.Wheelchair))
 		if err != nil {
 			r.LogDB(err)
 			return &item, err
 		}
+		st.Translations = stationTranslations[st.Id]
 		item.Stations = append(item.Stations, st)
 	}
 
@@ -126,32 +164,43 @@ func (r *repository) GetRoute(ctx context.Context, id int) (model.Model, error)
 
 func (r *repository) GetStation(ctx context.Context, id int) (model.Model, error) {
 	var item model.Station
-	sql := "SELECT id, name FROM station WHERE id=$1"
-	if err := r.client.QueryRow(ctx, sql, id).Scan(&item.Id, &item.Name); err != nil {
+	sql := "SELECT id, name, lat, lon, zone_id, wheelchair FROM station WHERE id=$1"
+	err := r.client.QueryRow(ctx, sql, id).Scan(&item.Id, &item.Name, &item.Lat, &item.Lon, &item.ZoneId, (*int)(&item.Wheelchair))
+	if err != nil {
 		r.LogDB(err)
 		return &item, err
 	}
+	translations, err := r.GetTranslations(ctx, item.DBTable())
+	if err != nil {
+		return &item, err
+	}
+	item.Translations = translations[item.Id]
 	return &item, nil
 }
 
 func (r *repository) GetStations(ctx context.Context) ([]model.Model, error) {
-	sql := "SELECT id, name FROM station ORDER BY name"
+	sql := "SELECT id, name, lat, lon, zone_id, wheelchair FROM station ORDER BY name"
 	rows, err := r.client.Query(ctx, sql)
 	if err != nil {
 		r.LogDB(err)
 		return nil, err
 	}
 	defer rows.Close()
+	translations, err := r.GetTranslations(ctx, "station")
+	if err != nil {
+		return nil, err
+	}
 
 	items := make([]model.Model, 0)
 
 	for rows.Next() {
 		var item model.Station
-		err = rows.Scan(&item.Id, &item.Name)
+		err = rows.Scan(&item.Id, &item.Name, &item.Lat, &item.Lon, &item.ZoneId, (*int)(&item.Wheelchair))
 		if err != nil {
 			r.LogDB(err)
 			return nil, err
 		}
+		item.Translations = translations[item.Id]
 		items = append(items, &item)
 	}
 
@@ -159,6 +208,23 @@ func (r *repository) GetStations(ctx context.Context) ([]model.Model, error) {
 }
 
 func (r *repository) Update(ctx context.Context, item model.Model) error {
+	switch v := item.(type) {
+	case *model.Calendar:
+		if v.Id == 0 {
+			return errors.New("calendar id is required")
+		}
+		return r.saveCalendar(ctx, v)
+	case *model.Station:
+		if v.Id == 0 {
+			return errors.New("station id is required")
+		}
+		return r.saveStation(ctx, v)
+	case *model.Route:
+		if v.Id == 0 {
+			return errors.New("route id is required")
+		}
+		return r.saveRoute(ctx, v)
+	}
 	sql := fmt.Sprintf("UPDATE %s SET name=$1 WHERE id=$2", item.DBTable())
 	_, err := r.client.Query(ctx, sql, item.GetName(), item.GetID())
 	if err != nil {
@@ -168,41 +234,66 @@ func (r *repository) Update(ctx context.Context, item model.Model) error {
 	return nil
 }
 
-func (r *repository) FindBus(ctx context.Context, fromId int, toId int) ([]model.Model, error) {
-	sqlFind := `SELECT r.id, r.name
-			FROM route_stations rs
-			JOIN route r ON r.id=rs.route_id
-			WHERE rs.station_id=@fromId OR rs.station_id=@toId
-			GROUP BY r.id
-			HAVING COUNT(DISTINCT rs.station_id)=2
-			AND
-			MIN (CASE WHEN rs.station_id=@fromId THEN rs.pos END)
-			 < MIN (CASE WHEN rs.station_id=@toId THEN rs.pos END)
-	ORDER BY name`
-	args := pgx.NamedArgs{
-		"fromId": fromId,
-		"toId":   toId,
-	}
-	rowsFind, err := r.client.Query(ctx, sqlFind, args)
+func (r *repository) GetRouteStations(ctx context.Context) ([]model.RouteStation, error) {
+	sql := `SELECT rs.id, r.id, r.name, s.id, s.name, rs.pos, s.zone_id, s.lat, s.lon
+		FROM route_stations rs
+		JOIN route r ON r.id=rs.route_id
+		JOIN station s ON s.id=rs.station_id
+		ORDER BY rs.route_id, rs.pos`
+	rows, err := r.client.Query(ctx, sql)
+	if err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.RouteStation, 0)
+
+	for rows.Next() {
+		var item model.RouteStation
+		err = rows.Scan(&item.Id, &item.RouteId, &item.RouteName, &item.StationId, &item.StationName, &item.Pos,
+			&item.ZoneId, &item.Lat, &item.Lon)
+		if err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (r *repository) GetStopTimes(ctx context.Context) ([]model.StopTime, error) {
+	sql := `SELECT rs.id, r.id, r.name, s.id, s.name, rs.pos, tr.id, tr.queue, COALESCE(tr.headsign, ''),
+			EXTRACT(EPOCH FROM t.stop_time)::int AS stop_time, COALESCE(tr.calendar_id, 0), tr.wheelchair
+		FROM route_stations_time t
+		JOIN trip tr ON tr.id=t.trip_id
+		JOIN route_stations rs ON rs.id=t.route_station_id
+		JOIN route r ON r.id=rs.route_id
+		JOIN station s ON s.id=rs.station_id
+		ORDER BY rs.route_id, tr.queue, rs.pos`
+	rows, err := r.client.Query(ctx, sql)
 	if err != nil {
 		r.LogDB(err)
 		return nil, err
 	}
-	defer rowsFind.Close()
+	defer rows.Close()
 
-	routes := make([]model.Model, 0)
+	items := make([]model.StopTime, 0)
 
-	for rowsFind.Next() {
-		var route model.Route
-		err = rowsFind.Scan(&route.Id, &route.Name)
+	for rows.Next() {
+		var item model.StopTime
+		err = rows.Scan(&item.RouteStationId, &item.RouteId, &item.RouteName, &item.StationId, &item.StationName,
+			&item.Pos, &item.TripId, &item.Queue, &item.Headsign, &item.Time, &item.CalendarId,
+			(*int)(&item.Wheelchair))
 		if err != nil {
 			r.LogDB(err)
 			return nil, err
 		}
-		routes = append(routes, &route)
+		items = append(items, item)
 	}
 
-	return routes, nil
+	return items, nil
 }
 
 func (r *repository) LogDB(err error) {
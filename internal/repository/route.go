@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *repository) saveRoute(ctx context.Context, item *model.Route) error {
+	if item.PricePerKm != nil && *item.PricePerKm < 0 {
+		return fmt.Errorf("wrong price per km: %d", *item.PricePerKm)
+	}
+
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"id":         item.Id,
+		"name":       item.Name,
+		"pricePerKm": item.PricePerKm,
+	}
+	if item.Id == 0 {
+		sql := "INSERT INTO route (name, price_per_km) VALUES (@name, @pricePerKm) RETURNING id"
+		if err = tx.QueryRow(ctx, sql, args).Scan(&item.Id); err != nil {
+			r.LogDB(err)
+			return err
+		}
+	} else {
+		sql := "UPDATE route SET name=@name, price_per_km=@pricePerKm WHERE id=@id"
+		if _, err = tx.Exec(ctx, sql, args); err != nil {
+			r.LogDB(err)
+			return err
+		}
+	}
+
+	if err = r.saveTranslations(ctx, tx, item.DBTable(), item.Id, item.Translations); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		r.LogDB(err)
+		return err
+	}
+	return nil
+}
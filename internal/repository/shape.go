@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetShapes returns the shape of the route, or of every route that has one
+// when routeId is 0.
+func (r *repository) GetShapes(ctx context.Context, routeId int) ([]model.Shape, error) {
+	sql := `SELECT route_id, lat, lon
+		FROM route_shape
+		WHERE @routeId=0 OR route_id=@routeId
+		ORDER BY route_id, seq`
+	rows, err := r.client.Query(ctx, sql, pgx.NamedArgs{"routeId": routeId})
+	if err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.Shape, 0)
+	for rows.Next() {
+		var id int
+		var p model.Point
+		if err = rows.Scan(&id, &p.Lat, &p.Lon); err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+		if len(items) == 0 || items[len(items)-1].RouteId != id {
+			items = append(items, model.Shape{RouteId: id})
+		}
+		last := &items[len(items)-1]
+		last.Points = append(last.Points, p)
+	}
+	for i := range items {
+		items[i].Polyline = model.EncodePolyline(items[i].Points)
+	}
+
+	return items, nil
+}
+
+// SaveShape replaces the shape of the route, no points removes it.
+func (r *repository) SaveShape(ctx context.Context, item *model.Shape) error {
+	for _, p := range item.Points {
+		if p.Lat < -90 || p.Lat > 90 || p.Lon < -180 || p.Lon > 180 {
+			return fmt.Errorf("wrong coordinates: %f, %f", p.Lat, p.Lon)
+		}
+	}
+
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, "DELETE FROM route_shape WHERE route_id=$1", item.RouteId); err != nil {
+		r.LogDB(err)
+		return err
+	}
+	sql := "INSERT INTO route_shape (route_id, seq, lat, lon) VALUES (@routeId, @seq, @lat, @lon)"
+	for i, p := range item.Points {
+		args := pgx.NamedArgs{
+			"routeId": item.RouteId,
+			"seq":     i,
+			"lat":     p.Lat,
+			"lon":     p.Lon,
+		}
+		if _, err = tx.Exec(ctx, sql, args); err != nil {
+			r.LogDB(err)
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.LogDB(err)
+		return err
+	}
+	item.Polyline = model.EncodePolyline(item.Points)
+	return nil
+}
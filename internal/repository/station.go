@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// distanceSQL is the haversine distance in meters between station s and
+// the @lat/@lon point.
+const distanceSQL = `2 * 6371000 * asin(sqrt(
+		power(sin(radians(s.lat - @lat) / 2), 2) +
+		cos(radians(@lat)) * cos(radians(s.lat)) * power(sin(radians(s.lon - @lon) / 2), 2)
+	))`
+
+func (r *repository) saveStation(ctx context.Context, item *model.Station) error {
+	if (item.Lat == nil) != (item.Lon == nil) {
+		return errors.New("lat and lon must be set together")
+	}
+	if item.Lat != nil && (*item.Lat < -90 || *item.Lat > 90 || *item.Lon < -180 || *item.Lon > 180) {
+		return fmt.Errorf("wrong coordinates: %f, %f", *item.Lat, *item.Lon)
+	}
+
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"id":         item.Id,
+		"name":       item.Name,
+		"lat":        item.Lat,
+		"lon":        item.Lon,
+		"zoneId":     item.ZoneId,
+		"wheelchair": int(item.Wheelchair),
+	}
+	if item.Id == 0 {
+		sql := `INSERT INTO station (name, lat, lon, zone_id, wheelchair)
+			VALUES (@name, @lat, @lon, @zoneId, @wheelchair)
+			RETURNING id`
+		if err = tx.QueryRow(ctx, sql, args).Scan(&item.Id); err != nil {
+			r.LogDB(err)
+			return err
+		}
+	} else {
+		sql := "UPDATE station SET name=@name, lat=@lat, lon=@lon, zone_id=@zoneId, wheelchair=@wheelchair WHERE id=@id"
+		if _, err = tx.Exec(ctx, sql, args); err != nil {
+			r.LogDB(err)
+			return err
+		}
+	}
+
+	if err = r.saveTranslations(ctx, tx, item.DBTable(), item.Id, item.Translations); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		r.LogDB(err)
+		return err
+	}
+	return nil
+}
+
+func (r *repository) GetNearbyStations(ctx context.Context, lat float64, lon float64, radius float64) ([]model.NearbyStation, error) {
+	sql := `SELECT id, name, lat, lon, wheelchair, distance FROM (
+			SELECT s.id, s.name, s.lat, s.lon, s.wheelchair, ` + distanceSQL + ` AS distance
+			FROM station s
+			WHERE s.lat IS NOT NULL AND s.lon IS NOT NULL
+		) t
+		WHERE distance <= @radius
+		ORDER BY distance, name`
+	args := pgx.NamedArgs{
+		"lat":    lat,
+		"lon":    lon,
+		"radius": radius,
+	}
+	rows, err := r.client.Query(ctx, sql, args)
+	if err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.NearbyStation, 0)
+	for rows.Next() {
+		var item model.NearbyStation
+		err = rows.Scan(&item.Id, &item.Name, &item.Lat, &item.Lon, (*int)(&item.Wheelchair), &item.Distance)
+		if err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (r *repository) GetStationsVersion(ctx context.Context) (int64, error) {
+	var version int64
+	if err := r.client.QueryRow(ctx, "SELECT last_value FROM station_version").Scan(&version); err != nil {
+		r.LogDB(err)
+		return 0, err
+	}
+	return version, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *repository) GetTransfers(ctx context.Context) ([]model.Transfer, error) {
+	sql := `SELECT t.id, f.id, f.name, s.id, s.name, t.walk_minutes
+		FROM station_transfer t
+		JOIN station f ON f.id=t.from_station_id
+		JOIN station s ON s.id=t.to_station_id
+		ORDER BY f.name, s.name`
+	rows, err := r.client.Query(ctx, sql)
+	if err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.Transfer, 0)
+	for rows.Next() {
+		var item model.Transfer
+		err = rows.Scan(&item.Id, &item.FromId, &item.FromName, &item.ToId, &item.ToName, &item.WalkMinutes)
+		if err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (r *repository) CreateTransfer(ctx context.Context, item *model.Transfer) error {
+	return r.saveTransfer(ctx, item)
+}
+
+func (r *repository) UpdateTransfer(ctx context.Context, item *model.Transfer) error {
+	if item.Id == 0 {
+		return errors.New("transfer id is required")
+	}
+	return r.saveTransfer(ctx, item)
+}
+
+func (r *repository) DeleteTransfer(ctx context.Context, id int) error {
+	tag, err := r.client.Exec(ctx, "DELETE FROM station_transfer WHERE id=$1", id)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("transfer %d not found", id)
+	}
+	return nil
+}
+
+func (r *repository) saveTransfer(ctx context.Context, item *model.Transfer) error {
+	args := pgx.NamedArgs{
+		"id":          item.Id,
+		"fromId":      item.FromId,
+		"toId":        item.ToId,
+		"walkMinutes": item.WalkMinutes,
+	}
+	sql := `WITH t AS (
+			INSERT INTO station_transfer (from_station_id, to_station_id, walk_minutes)
+			VALUES (@fromId, @toId, @walkMinutes)
+			RETURNING id, from_station_id, to_station_id
+		)
+		SELECT t.id, f.name, s.name
+		FROM t
+		JOIN station f ON f.id=t.from_station_id
+		JOIN station s ON s.id=t.to_station_id`
+	if item.Id != 0 {
+		sql = `WITH t AS (
+				UPDATE station_transfer SET from_station_id=@fromId, to_station_id=@toId, walk_minutes=@walkMinutes
+				WHERE id=@id
+				RETURNING id, from_station_id, to_station_id
+			)
+			SELECT t.id, f.name, s.name
+			FROM t
+			JOIN station f ON f.id=t.from_station_id
+			JOIN station s ON s.id=t.to_station_id`
+	}
+	err := r.client.QueryRow(ctx, sql, args).Scan(&item.Id, &item.FromName, &item.ToName)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("transfer %d not found", item.Id)
+	}
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	return nil
+}
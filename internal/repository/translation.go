@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetTranslations returns the translated names of every row of the table,
+// "station" or "route", by id.
+func (r *repository) GetTranslations(ctx context.Context, table string) (map[int]model.Translations, error) {
+	sql := fmt.Sprintf("SELECT %s_id, lang, name FROM %s_translation ORDER BY lang", table, table)
+	rows, err := r.client.Query(ctx, sql)
+	if err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make(map[int]model.Translations)
+	for rows.Next() {
+		var id int
+		var lang, name string
+		if err = rows.Scan(&id, &lang, &name); err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+		if items[id] == nil {
+			items[id] = make(model.Translations)
+		}
+		items[id][lang] = name
+	}
+
+	return items, nil
+}
+
+// saveTranslations replaces the translations of the row, nil keeps the
+// stored ones.
+func (r *repository) saveTranslations(ctx context.Context, tx pgx.Tx, table string, id int, translations model.Translations) error {
+	if translations == nil {
+		return nil
+	}
+
+	sql := fmt.Sprintf("DELETE FROM %s_translation WHERE %s_id=$1", table, table)
+	if _, err := tx.Exec(ctx, sql, id); err != nil {
+		r.LogDB(err)
+		return err
+	}
+	sql = fmt.Sprintf("INSERT INTO %s_translation (%s_id, lang, name) VALUES ($1, $2, $3)", table, table)
+	for lang, name := range translations {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang == "" || name == "" {
+			return fmt.Errorf("wrong translation %q: %q", lang, name)
+		}
+		if _, err := tx.Exec(ctx, sql, id, lang, name); err != nil {
+			r.LogDB(err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *repository) GetTrips(ctx context.Context, routeId int) ([]model.Trip, error) {
+	sql := "SELECT id, route_id, queue, calendar_id, COALESCE(headsign, ''), wheelchair FROM trip WHERE route_id=$1 ORDER BY queue"
+	rows, err := r.client.Query(ctx, sql, routeId)
+	if err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.Trip, 0)
+	byId := make(map[int]int)
+	for rows.Next() {
+		var item model.Trip
+		if err = rows.Scan(&item.Id, &item.RouteId, &item.Queue, &item.CalendarId, &item.Headsign, (*int)(&item.Wheelchair)); err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+		item.StopTimes = []model.TripStopTime{}
+		byId[item.Id] = len(items)
+		items = append(items, item)
+	}
+
+	sqlTimes := `SELECT t.trip_id, s.id, s.name, rs.pos, EXTRACT(EPOCH FROM t.stop_time)::int
+		FROM route_stations_time t
+		JOIN route_stations rs ON rs.id=t.route_station_id
+		JOIN station s ON s.id=rs.station_id
+		WHERE rs.route_id=$1
+		ORDER BY rs.pos`
+	rowsTimes, err := r.client.Query(ctx, sqlTimes, routeId)
+	if err != nil {
+		r.LogDB(err)
+		return nil, err
+	}
+	defer rowsTimes.Close()
+	for rowsTimes.Next() {
+		var tripId, stopTime int
+		var st model.TripStopTime
+		if err = rowsTimes.Scan(&tripId, &st.StationId, &st.StationName, &st.Pos, &stopTime); err != nil {
+			r.LogDB(err)
+			return nil, err
+		}
+		st.Time = model.FormatClock(stopTime)
+		if i, ok := byId[tripId]; ok {
+			items[i].StopTimes = append(items[i].StopTimes, st)
+		}
+	}
+
+	return items, nil
+}
+
+func (r *repository) GetTrip(ctx context.Context, routeId int, id int) (model.Trip, error) {
+	trips, err := r.GetTrips(ctx, routeId)
+	if err != nil {
+		return model.Trip{}, err
+	}
+	for _, item := range trips {
+		if item.Id == id {
+			return item, nil
+		}
+	}
+	return model.Trip{}, fmt.Errorf("trip %d not found on route %d", id, routeId)
+}
+
+// CreateTrip stores the trip with its stop times, the queue is always the
+// next free number on the route.
+func (r *repository) CreateTrip(ctx context.Context, item *model.Trip) error {
+	return r.CreateTrips(ctx, []*model.Trip{item})
+}
+
+// CreateTrips stores all trips in one transaction, see CreateTrip.
+func (r *repository) CreateTrips(ctx context.Context, items []*model.Trip) error {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	sql := `INSERT INTO trip (route_id, queue, calendar_id, headsign, wheelchair)
+		SELECT @routeId, COALESCE(MAX(queue)+1, 0), @calendarId, NULLIF(@headsign, ''), @wheelchair
+		FROM trip WHERE route_id=@routeId
+		RETURNING id, queue`
+	for _, item := range items {
+		args := pgx.NamedArgs{
+			"routeId":    item.RouteId,
+			"calendarId": item.CalendarId,
+			"headsign":   item.Headsign,
+			"wheelchair": int(item.Wheelchair),
+		}
+		if err = tx.QueryRow(ctx, sql, args).Scan(&item.Id, &item.Queue); err != nil {
+			r.LogDB(err)
+			return err
+		}
+
+		if err = r.insertTripStopTimes(ctx, tx, item); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.LogDB(err)
+		return err
+	}
+	return nil
+}
+
+func (r *repository) UpdateTrip(ctx context.Context, item *model.Trip) error {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	sql := `UPDATE trip SET queue=@queue, calendar_id=@calendarId, headsign=NULLIF(@headsign, ''), wheelchair=@wheelchair
+		WHERE id=@id AND route_id=@routeId`
+	args := pgx.NamedArgs{
+		"id":         item.Id,
+		"routeId":    item.RouteId,
+		"queue":      item.Queue,
+		"calendarId": item.CalendarId,
+		"headsign":   item.Headsign,
+		"wheelchair": int(item.Wheelchair),
+	}
+	tag, err := tx.Exec(ctx, sql, args)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("trip %d not found on route %d", item.Id, item.RouteId)
+	}
+
+	if _, err = tx.Exec(ctx, "DELETE FROM route_stations_time WHERE trip_id=$1", item.Id); err != nil {
+		r.LogDB(err)
+		return err
+	}
+	if err = r.insertTripStopTimes(ctx, tx, item); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		r.LogDB(err)
+		return err
+	}
+	return nil
+}
+
+func (r *repository) DeleteTrip(ctx context.Context, routeId int, id int) error {
+	sql := "DELETE FROM trip WHERE id=$1 AND route_id=$2"
+	tag, err := r.client.Exec(ctx, sql, id, routeId)
+	if err != nil {
+		r.LogDB(err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("trip %d not found on route %d", id, routeId)
+	}
+	return nil
+}
+
+func (r *repository) insertTripStopTimes(ctx context.Context, tx pgx.Tx, item *model.Trip) error {
+	sql := `WITH ins AS (
+			INSERT INTO route_stations_time (route_station_id, trip_id, stop_time)
+			SELECT rs.id, @tripId, @stopTime::interval
+			FROM route_stations rs
+			WHERE rs.route_id=@routeId AND rs.station_id=@stationId
+			RETURNING route_station_id
+		)
+		SELECT rs.pos, s.name
+		FROM ins
+		JOIN route_stations rs ON rs.id=ins.route_station_id
+		JOIN station s ON s.id=rs.station_id`
+	for i, st := range item.StopTimes {
+		args := pgx.NamedArgs{
+			"tripId":    item.Id,
+			"routeId":   item.RouteId,
+			"stationId": st.StationId,
+			"stopTime":  st.Time,
+		}
+		err := tx.QueryRow(ctx, sql, args).Scan(&item.StopTimes[i].Pos, &item.StopTimes[i].StationName)
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("station %d is not on route %d", st.StationId, item.RouteId)
+		}
+		if err != nil {
+			r.LogDB(err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+const day = 24 * 3600
+
+// activeStopTimes returns the stop times of the trips running on date,
+// including the virtual trips of route frequencies. Trips of the previous
+// service day that run past midnight are added shifted by a day from their
+// first stop after midnight, so a query at 00:10 still sees the bus
+// scheduled at 24:25:00 yesterday.
+func (s *busService) activeStopTimes(ctx context.Context, date time.Time) ([]model.StopTime, error) {
+	stopTimes, err := s.repository.GetStopTimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	calendars, err := s.repository.GetCalendars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	frequencies, err := s.repository.GetFrequencies(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range frequencies {
+		virtual, err := expandFrequency(f)
+		if err != nil {
+			return nil, err
+		}
+		stopTimes = append(stopTimes, virtual...)
+	}
+
+	yesterday := date.AddDate(0, 0, -1)
+	active := make(map[int]bool, len(calendars))
+	activeYesterday := make(map[int]bool, len(calendars))
+	for _, item := range calendars {
+		if c, ok := item.(*model.Calendar); ok {
+			active[c.Id] = c.Active(date)
+			activeYesterday[c.Id] = c.Active(yesterday)
+		}
+	}
+
+	overnight := make(map[tripKey]bool)
+	for _, st := range stopTimes {
+		if st.Time >= day {
+			overnight[tripKey{routeId: st.RouteId, frequencyId: st.FrequencyId, queue: st.Queue}] = true
+		}
+	}
+
+	items := make([]model.StopTime, 0, len(stopTimes))
+	for _, st := range stopTimes {
+		if st.CalendarId == 0 || active[st.CalendarId] {
+			items = append(items, st)
+		}
+		key := tripKey{routeId: st.RouteId, frequencyId: st.FrequencyId, queue: st.Queue}
+		if st.Time >= day && overnight[key] && (st.CalendarId == 0 || activeYesterday[st.CalendarId]) {
+			st.Time -= day
+			st.DayOffset = -1
+			items = append(items, st)
+		}
+	}
+	s.withRealtime(items, date)
+	return items, nil
+}
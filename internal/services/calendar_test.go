@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// scheduleRepository serves stored stop times without calendars or
+// frequencies.
+type scheduleRepository struct {
+	model.Repository
+	stopTimes []model.StopTime
+}
+
+func (r *scheduleRepository) GetStopTimes(context.Context) ([]model.StopTime, error) {
+	return r.stopTimes, nil
+}
+
+func (r *scheduleRepository) GetCalendars(context.Context) ([]model.Model, error) {
+	return nil, nil
+}
+
+func (r *scheduleRepository) GetFrequencies(context.Context, int) ([]model.Frequency, error) {
+	return nil, nil
+}
+
+func TestActiveStopTimesOvernight(t *testing.T) {
+	h := func(v string) int { return clock(t, v) }
+	// 23:40 at station 1 until 24:20 at station 5
+	s := &busService{repository: &scheduleRepository{stopTimes: tripTimes(1, 0, h("23:40"), 1, 2, 3, 4, 5)}}
+	stopTimes, err := s.activeStopTimes(context.Background(), time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range stopTimes {
+		if st.Time < 0 {
+			t.Fatalf("station %d at %d seconds", st.StationId, st.Time)
+		}
+	}
+
+	tt := newTimetable(stopTimes, nil)
+	arriveBy := h("01:00")
+	tests := []struct {
+		name      string
+		from      int
+		departure string
+	}{
+		{name: "stop after midnight", from: 4, departure: "00:10:00"},
+		{name: "stop before midnight", from: 1},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			journeys := tt.latestDeparture(model.JourneyQuery{FromId: tc.from, ToId: 5, ArriveBy: &arriveBy})
+			for _, j := range journeys {
+				for _, leg := range j.Legs {
+					if strings.Contains(leg.Departure+leg.Arrival, "-") {
+						t.Fatalf("leg %s - %s", leg.Departure, leg.Arrival)
+					}
+				}
+			}
+			if tc.departure == "" {
+				if len(journeys) != 0 {
+					t.Fatalf("got %d journeys, want none", len(journeys))
+				}
+				return
+			}
+			if len(journeys) != 1 {
+				t.Fatalf("got %d journeys, want 1", len(journeys))
+			}
+			if got := journeys[0].Legs[0].Departure; got != tc.departure {
+				t.Errorf("departure %s, want %s", got, tc.departure)
+			}
+		})
+	}
+}
@@ -0,0 +1,417 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+const (
+	// utf8BOM makes Excel read the file as UTF-8
+	utf8BOM = "\ufeff"
+	// translationPrefix starts the columns holding the name translations,
+	// name_en is the English name
+	translationPrefix = "name_"
+	idColumn          = "id"
+	// maxCSVErrors caps the report of a file that is wrong on every row
+	maxCSVErrors = 100
+)
+
+// csvColumn reads a column value from an item and writes it back, an empty
+// cell is the zero value of the field.
+type csvColumn struct {
+	name string
+	get  func(item model.Model) string
+	set  func(item model.Model, v string) error
+}
+
+type csvTable struct {
+	entity  string
+	columns []csvColumn
+	newItem func() model.Model
+	list    func(ctx context.Context) ([]model.Model, error)
+	// translations returns the translations field of the item
+	translations func(item model.Model) *model.Translations
+}
+
+func (s *busService) stationsTable() csvTable {
+	station := func(item model.Model) *model.Station { return item.(*model.Station) }
+	return csvTable{
+		entity: "station",
+		columns: []csvColumn{
+			{
+				name: "name",
+				get:  func(item model.Model) string { return station(item).Name },
+				set: func(item model.Model, v string) error {
+					station(item).Name = v
+					return requiredCell(v)
+				},
+			},
+			{
+				name: "lat",
+				get:  func(item model.Model) string { return formatFloatCell(station(item).Lat) },
+				set: func(item model.Model, v string) (err error) {
+					station(item).Lat, err = parseFloatCell(v)
+					return err
+				},
+			},
+			{
+				name: "lon",
+				get:  func(item model.Model) string { return formatFloatCell(station(item).Lon) },
+				set: func(item model.Model, v string) (err error) {
+					station(item).Lon, err = parseFloatCell(v)
+					return err
+				},
+			},
+			{
+				name: "zone_id",
+				get:  func(item model.Model) string { return formatIntCell(station(item).ZoneId) },
+				set: func(item model.Model, v string) (err error) {
+					station(item).ZoneId, err = parseIntCell(v)
+					return err
+				},
+			},
+			{
+				name: "wheelchair",
+				get: func(item model.Model) string {
+					text, _ := station(item).Wheelchair.MarshalText()
+					return string(text)
+				},
+				set: func(item model.Model, v string) error {
+					if v == "" {
+						station(item).Wheelchair = model.WheelchairUnknown
+						return nil
+					}
+					return station(item).Wheelchair.UnmarshalText([]byte(v))
+				},
+			},
+		},
+		newItem:      func() model.Model { return &model.Station{} },
+		list:         s.repository.GetStations,
+		translations: func(item model.Model) *model.Translations { return &station(item).Translations },
+	}
+}
+
+func (s *busService) routesTable() csvTable {
+	route := func(item model.Model) *model.Route { return item.(*model.Route) }
+	return csvTable{
+		entity: "route",
+		columns: []csvColumn{
+			{
+				name: "name",
+				get:  func(item model.Model) string { return route(item).Name },
+				set: func(item model.Model, v string) error {
+					route(item).Name = v
+					return requiredCell(v)
+				},
+			},
+			{
+				name: "price_per_km",
+				get:  func(item model.Model) string { return formatIntCell(route(item).PricePerKm) },
+				set: func(item model.Model, v string) (err error) {
+					route(item).PricePerKm, err = parseIntCell(v)
+					return err
+				},
+			},
+		},
+		newItem:      func() model.Model { return &model.Route{} },
+		list:         s.repository.GetRoutes,
+		translations: func(item model.Model) *model.Translations { return &route(item).Translations },
+	}
+}
+
+func (s *busService) ExportStationsCSV(ctx context.Context, w io.Writer, sep rune) error {
+	return s.exportCSV(ctx, w, sep, s.stationsTable())
+}
+
+func (s *busService) ExportRoutesCSV(ctx context.Context, w io.Writer, sep rune) error {
+	return s.exportCSV(ctx, w, sep, s.routesTable())
+}
+
+// ImportStationsCSV creates the rows without an id and updates the others,
+// see importCSV.
+func (s *busService) ImportStationsCSV(ctx context.Context, r io.Reader) (model.CSVReport, error) {
+	return s.importCSV(ctx, r, s.stationsTable())
+}
+
+func (s *busService) ImportRoutesCSV(ctx context.Context, r io.Reader) (model.CSVReport, error) {
+	return s.importCSV(ctx, r, s.routesTable())
+}
+
+// exportCSV writes a header row, the id and the table columns followed by
+// one name_<lang> column for every language any item is translated to.
+func (s *busService) exportCSV(ctx context.Context, w io.Writer, sep rune, t csvTable) error {
+	items, err := t.list(ctx)
+	if err != nil {
+		return err
+	}
+	langSet := make(map[string]bool)
+	for _, item := range items {
+		for lang := range *t.translations(item) {
+			langSet[lang] = true
+		}
+	}
+	langs := make([]string, 0, len(langSet))
+	for lang := range langSet {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	if _, err = io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = sep
+	cw.UseCRLF = true
+
+	header := []string{idColumn}
+	for _, c := range t.columns {
+		header = append(header, c.name)
+	}
+	for _, lang := range langs {
+		header = append(header, translationPrefix+lang)
+	}
+	cw.Write(header)
+	for _, item := range items {
+		row := []string{strconv.Itoa(item.GetID())}
+		for _, c := range t.columns {
+			row = append(row, c.get(item))
+		}
+		translations := *t.translations(item)
+		for _, lang := range langs {
+			row = append(row, translations[lang])
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// importCSV saves the rows one by one. A row with an id updates that item
+// and keeps the fields of the columns the file leaves out, a row without
+// one creates an item. When the file has name_<lang> columns they replace
+// the stored translations. The separator is guessed from the header.
+func (s *busService) importCSV(ctx context.Context, r io.Reader, t csvTable) (model.CSVReport, error) {
+	report := model.CSVReport{Errors: make([]model.RowError, 0)}
+
+	br := bufio.NewReader(r)
+	if b, _ := br.Peek(len(utf8BOM)); string(b) == utf8BOM {
+		br.Discard(len(utf8BOM))
+	}
+	cr := csv.NewReader(br)
+	cr.Comma = sniffSeparator(br)
+	cr.TrimLeadingSpace = true
+	// spreadsheets leave quotes inside unquoted cells as they are
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return report, errors.New("empty CSV file")
+	}
+	if err != nil {
+		return report, err
+	}
+	columns := make(map[int]csvColumn)
+	langs := make(map[int]string)
+	idIndex := -1
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == "":
+		case name == idColumn:
+			idIndex = i
+		case strings.HasPrefix(name, translationPrefix):
+			langs[i] = strings.TrimPrefix(name, translationPrefix)
+		default:
+			c, ok := t.column(name)
+			if !ok {
+				return report, fmt.Errorf("unknown column %q", name)
+			}
+			columns[i] = c
+		}
+	}
+
+	all, err := t.list(ctx)
+	if err != nil {
+		return report, err
+	}
+	existing := make(map[int]model.Model, len(all))
+	for _, item := range all {
+		existing[item.GetID()] = item
+	}
+
+	rowError := func(line int, err error) {
+		if len(report.Errors) < maxCSVErrors {
+			report.Errors = append(report.Errors, model.RowError{Line: line, Error: err.Error()})
+		}
+	}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// the reader goes on with the next row after a parse error
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return report, err
+			}
+			rowError(parseErr.StartLine, parseErr.Err)
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		if blankRow(row) {
+			continue
+		}
+
+		item, err := t.rowItem(row, idIndex, existing)
+		if err == nil {
+			err = t.setRow(item, row, columns, langs)
+		}
+		if err != nil {
+			rowError(line, err)
+			continue
+		}
+		if item.GetID() == 0 {
+			if item.GetName() == "" {
+				rowError(line, errors.New("name is required"))
+				continue
+			}
+			if err = s.repository.Create(ctx, item); err != nil {
+				rowError(line, err)
+				continue
+			}
+			report.Created++
+		} else {
+			if err = s.repository.Update(ctx, item); err != nil {
+				rowError(line, err)
+				continue
+			}
+			report.Updated++
+		}
+	}
+	return report, nil
+}
+
+func (t csvTable) column(name string) (csvColumn, bool) {
+	for _, c := range t.columns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return csvColumn{}, false
+}
+
+// rowItem returns the stored item the row updates, or a new one when the
+// row has no id.
+func (t csvTable) rowItem(row []string, idIndex int, existing map[int]model.Model) (model.Model, error) {
+	if idIndex < 0 || strings.TrimSpace(row[idIndex]) == "" {
+		return t.newItem(), nil
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(row[idIndex]))
+	if err != nil {
+		return nil, fmt.Errorf("wrong id: %q", row[idIndex])
+	}
+	item, ok := existing[id]
+	if !ok {
+		return nil, fmt.Errorf("%s %d not found", t.entity, id)
+	}
+	return item, nil
+}
+
+func (t csvTable) setRow(item model.Model, row []string, columns map[int]csvColumn, langs map[int]string) error {
+	for i, c := range columns {
+		if err := c.set(item, strings.TrimSpace(row[i])); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+	if len(langs) == 0 {
+		return nil
+	}
+	translations := make(model.Translations, len(langs))
+	for i, lang := range langs {
+		if v := strings.TrimSpace(row[i]); v != "" {
+			translations[lang] = v
+		}
+	}
+	*t.translations(item) = translations
+	return nil
+}
+
+// sniffSeparator picks the most frequent of the separators spreadsheets
+// use in the header line, Excel saves with ";" in many locales.
+func sniffSeparator(br *bufio.Reader) rune {
+	b, _ := br.Peek(br.Size())
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	sep, most := ',', bytes.Count(b, []byte{','})
+	for _, c := range []rune{';', '\t'} {
+		if n := bytes.Count(b, []byte(string(c))); n > most {
+			sep, most = c, n
+		}
+	}
+	return sep
+}
+
+func blankRow(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func requiredCell(v string) error {
+	if v == "" {
+		return errors.New("value is required")
+	}
+	return nil
+}
+
+func parseFloatCell(v string) (*float64, error) {
+	if v == "" {
+		return nil, nil
+	}
+	// spreadsheets in many locales write a decimal comma
+	f, err := strconv.ParseFloat(strings.Replace(v, ",", ".", 1), 64)
+	if err != nil {
+		return nil, fmt.Errorf("wrong number: %q", v)
+	}
+	return &f, nil
+}
+
+func parseIntCell(v string) (*int, error) {
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("wrong number: %q", v)
+	}
+	return &n, nil
+}
+
+func formatFloatCell(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func formatIntCell(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// stationsRepository stores one station and records the saved ones.
+type stationsRepository struct {
+	model.Repository
+	saved []*model.Station
+}
+
+func (r *stationsRepository) GetStations(context.Context) ([]model.Model, error) {
+	return []model.Model{&model.Station{Id: 1, Name: "Kupchino"}}, nil
+}
+
+func (r *stationsRepository) Create(_ context.Context, item model.Model) error {
+	item.SetID(len(r.saved) + 100)
+	r.saved = append(r.saved, item.(*model.Station))
+	return nil
+}
+
+func (r *stationsRepository) Update(_ context.Context, item model.Model) error {
+	r.saved = append(r.saved, item.(*model.Station))
+	return nil
+}
+
+func TestImportStationsCSV(t *testing.T) {
+	in := strings.Join([]string{
+		"id;name;lat;lon",
+		`;Park "North;;`,
+		";too;many;cells;here",
+		"1;Kupchino 2;59,95;30.3",
+		`2";bare quote in the first cell;;`,
+		";bad lat;abc;",
+		`;"Quoted; name";;`,
+	}, "\r\n")
+	repo := &stationsRepository{}
+	s := &busService{repository: repo}
+	report, err := s.ImportStationsCSV(context.Background(), strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Created != 2 || report.Updated != 1 {
+		t.Errorf("created %d, updated %d, want 2 and 1", report.Created, report.Updated)
+	}
+	names := make([]string, 0, len(repo.saved))
+	for _, st := range repo.saved {
+		names = append(names, st.Name)
+	}
+	want := []string{`Park "North`, "Kupchino 2", "Quoted; name"}
+	if strings.Join(names, "|") != strings.Join(want, "|") {
+		t.Errorf("saved %q, want %q", names, want)
+	}
+
+	lines := make([]int, 0, len(report.Errors))
+	for _, e := range report.Errors {
+		lines = append(lines, e.Line)
+	}
+	if len(lines) != 3 || lines[0] != 3 || lines[1] != 5 || lines[2] != 6 {
+		t.Errorf("errors %+v, want lines 3, 5 and 6", report.Errors)
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+func (s *busService) GetDepartures(ctx context.Context, stationId int, date time.Time, from int, limit int) ([]model.Departure, error) {
+	routeStations, err := s.repository.GetRouteStations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stopTimes, err := s.activeStopTimes(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	last := make(map[int]model.RouteStation)
+	for _, rs := range routeStations {
+		if l, ok := last[rs.RouteId]; !ok || rs.Pos > l.Pos {
+			last[rs.RouteId] = rs
+		}
+	}
+
+	type departure struct {
+		model.Departure
+		time int
+	}
+	found := make([]departure, 0)
+	for _, st := range stopTimes {
+		// a late bus is still listed until its predicted time
+		latest := st.Time
+		if st.Predicted != nil {
+			latest = max(latest, *st.Predicted)
+		}
+		if st.StationId != stationId || latest < from {
+			continue
+		}
+		terminus, ok := last[st.RouteId]
+		if !ok || terminus.Pos == st.Pos {
+			continue
+		}
+		headsign := st.Headsign
+		if headsign == "" {
+			headsign = terminus.StationName
+		}
+		d := departure{
+			Departure: model.Departure{
+				RouteId:     st.RouteId,
+				RouteName:   st.RouteName,
+				Headsign:    headsign,
+				Time:        model.FormatClock(st.Time),
+				TripId:      st.TripId,
+				FrequencyId: st.FrequencyId,
+				Queue:       st.Queue,
+				Wheelchair:  st.Wheelchair,
+				Canceled:    st.Canceled,
+			},
+			time: st.Time,
+		}
+		if st.Predicted != nil {
+			d.Predicted = model.FormatClock(*st.Predicted)
+		}
+		found = append(found, d)
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].time != found[j].time {
+			return found[i].time < found[j].time
+		}
+		return found[i].RouteName < found[j].RouteName
+	})
+
+	items := make([]model.Departure, 0)
+	for i := 0; i < len(found) && i < limit; i++ {
+		items = append(items, found[i].Departure)
+	}
+	return items, nil
+}
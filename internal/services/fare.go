@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+type fareTable struct {
+	currency string
+	prices   map[[2]int]int
+	perKm    map[int]int
+	routes   map[int][]model.RouteStation
+	zones    map[int]*int
+}
+
+func (s *busService) loadFares(ctx context.Context) (*fareTable, error) {
+	routeStations, err := s.repository.GetRouteStations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := s.repository.GetZonePrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := s.repository.GetRoutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ft := &fareTable{
+		currency: s.fareCfg.Currency,
+		prices:   make(map[[2]int]int, len(prices)),
+		perKm:    make(map[int]int),
+		routes:   make(map[int][]model.RouteStation),
+		zones:    make(map[int]*int),
+	}
+	for _, p := range prices {
+		ft.prices[[2]int{p.FromZoneId, p.ToZoneId}] = p.Price
+	}
+	for _, item := range routes {
+		if route, ok := item.(*model.Route); ok && route.PricePerKm != nil {
+			ft.perKm[route.Id] = *route.PricePerKm
+		}
+	}
+	for _, rs := range routeStations {
+		ft.routes[rs.RouteId] = append(ft.routes[rs.RouteId], rs)
+		ft.zones[rs.StationId] = rs.ZoneId
+	}
+	for _, stops := range ft.routes {
+		sort.Slice(stops, func(i, j int) bool { return stops[i].Pos < stops[j].Pos })
+	}
+	return ft, nil
+}
+
+// GetFare prices a single ride, routeId 0 prices it by zones only.
+func (s *busService) GetFare(ctx context.Context, fromId int, toId int, routeId int) (model.Fare, error) {
+	ft, err := s.loadFares(ctx)
+	if err != nil {
+		return model.Fare{}, err
+	}
+	return ft.rideFare(routeId, fromId, toId)
+}
+
+// rideFare is the zone price between the stations plus the distance
+// component of the route, at least one of them must apply.
+func (ft *fareTable) rideFare(routeId, fromId, toId int) (model.Fare, error) {
+	fare := model.Fare{Currency: ft.currency}
+
+	fromZone, toZone := ft.zones[fromId], ft.zones[toId]
+	zoned := fromZone != nil && toZone != nil
+	if zoned {
+		price, ok := ft.prices[[2]int{*fromZone, *toZone}]
+		if !ok {
+			return fare, fmt.Errorf("no price from zone %d to zone %d", *fromZone, *toZone)
+		}
+		fare.ZonePrice = price
+	}
+
+	perKm := 0
+	if routeId != 0 {
+		stops, err := ft.ride(routeId, fromId, toId)
+		if err != nil {
+			return fare, err
+		}
+		perKm = ft.perKm[routeId]
+		if perKm > 0 {
+			meters, err := rideDistance(stops)
+			if err != nil {
+				return fare, err
+			}
+			fare.Distance = math.Round(meters/10) / 100
+			fare.DistancePrice = int(math.Ceil(meters / 1000 * float64(perKm)))
+		}
+	}
+
+	if !zoned && perKm == 0 {
+		return fare, fmt.Errorf("no fare rule from station %d to station %d", fromId, toId)
+	}
+	fare.Amount = fare.ZonePrice + fare.DistancePrice
+	return fare, nil
+}
+
+// ride returns the route stations from fromId to toId inclusive.
+func (ft *fareTable) ride(routeId, fromId, toId int) ([]model.RouteStation, error) {
+	stops := ft.routes[routeId]
+	from := -1
+	for i, rs := range stops {
+		if rs.StationId == fromId {
+			from = i
+		}
+		if rs.StationId == toId && from >= 0 {
+			return stops[from : i+1], nil
+		}
+	}
+	return nil, fmt.Errorf("route %d does not go from station %d to station %d", routeId, fromId, toId)
+}
+
+func rideDistance(stops []model.RouteStation) (float64, error) {
+	for _, rs := range stops {
+		if rs.Lat == nil || rs.Lon == nil {
+			return 0, fmt.Errorf("station %d has no coordinates", rs.StationId)
+		}
+	}
+	meters := 0.0
+	for i := 1; i < len(stops); i++ {
+		a, b := stops[i-1], stops[i]
+		meters += model.Distance(*a.Lat, *a.Lon, *b.Lat, *b.Lon)
+	}
+	return meters, nil
+}
+
+// journeyFare sums the fares of every ride, walks are free. It is nil when
+// one of the rides can not be priced.
+func (ft *fareTable) journeyFare(j model.Journey) *model.Fare {
+	total := model.Fare{Currency: ft.currency}
+	for _, leg := range j.Legs {
+		if leg.Walk {
+			continue
+		}
+		fare, err := ft.rideFare(leg.RouteId, leg.Board.Id, leg.Alight.Id)
+		if err != nil {
+			return nil
+		}
+		total.Amount += fare.Amount
+		total.ZonePrice += fare.ZonePrice
+		total.DistancePrice += fare.DistancePrice
+		total.Distance += fare.Distance
+	}
+	return &total
+}
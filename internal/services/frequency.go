@@ -0,0 +1,45 @@
+package services
+
+import (
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// expandFrequency turns a headway definition into the stop times of its
+// virtual trips, Queue numbers the runs from 0.
+func expandFrequency(f model.Frequency) ([]model.StopTime, error) {
+	start, err := model.ParseClock(f.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	end, err := model.ParseClock(f.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.StopTime, 0)
+	if f.Headway <= 0 {
+		return items, nil
+	}
+	calendarId := 0
+	if f.CalendarId != nil {
+		calendarId = *f.CalendarId
+	}
+	for queue, t := 0, start; t < end; queue, t = queue+1, t+f.Headway*60 {
+		for _, o := range f.Offsets {
+			items = append(items, model.StopTime{
+				RouteStationId: o.RouteStationId,
+				RouteId:        f.RouteId,
+				RouteName:      f.RouteName,
+				StationId:      o.StationId,
+				StationName:    o.StationName,
+				Pos:            o.Pos,
+				FrequencyId:    f.Id,
+				Queue:          queue,
+				Time:           t + o.Minutes*60,
+				CalendarId:     calendarId,
+				Wheelchair:     f.Wheelchair,
+			})
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/gtfs"
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// ImportGTFS stores a GTFS zip in one transaction. Rows the schema can not
+// hold are skipped and listed in the report warnings.
+func (s *busService) ImportGTFS(ctx context.Context, r io.ReaderAt, size int64, opts model.ImportOptions) (model.ImportReport, error) {
+	feed, warnings, err := gtfs.Read(r, size)
+	if err != nil {
+		return model.ImportReport{}, err
+	}
+
+	report := model.ImportReport{
+		DryRun:    opts.DryRun,
+		Replace:   opts.Replace,
+		Stations:  len(feed.Stations),
+		Calendars: len(feed.Calendars),
+		Routes:    len(feed.Routes),
+		Warnings:  warnings,
+	}
+	for _, route := range feed.Routes {
+		report.Trips += len(route.Trips)
+		for _, trip := range route.Trips {
+			report.StopTimes += len(trip.Times)
+		}
+	}
+
+	if err = s.repository.ImportFeed(ctx, feed, opts); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// ExportGTFS writes the stored trips as a GTFS zip, the stations, routes and
+// calendars are loaded before the first byte is written.
+func (s *busService) ExportGTFS(ctx context.Context, w io.Writer) error {
+	t, err := s.gtfsTimetable(ctx)
+	if err != nil {
+		return err
+	}
+	return gtfs.Write(w, s.gtfsCfg, t)
+}
+
+func (s *busService) gtfsTimetable(ctx context.Context) (gtfs.Timetable, error) {
+	t := gtfs.Timetable{Start: time.Now()}
+
+	stations, err := s.repository.GetStations(ctx)
+	if err != nil {
+		return t, err
+	}
+	for _, item := range stations {
+		if st, ok := item.(*model.Station); ok {
+			t.Stations = append(t.Stations, st)
+		}
+	}
+	routes, err := s.repository.GetRoutes(ctx)
+	if err != nil {
+		return t, err
+	}
+	for _, item := range routes {
+		if r, ok := item.(*model.Route); ok {
+			t.Routes = append(t.Routes, r)
+		}
+	}
+	calendars, err := s.repository.GetCalendars(ctx)
+	if err != nil {
+		return t, err
+	}
+	for _, item := range calendars {
+		if c, ok := item.(*model.Calendar); ok {
+			t.Calendars = append(t.Calendars, c)
+		}
+	}
+	t.StopTimes, err = s.repository.GetStopTimes(ctx)
+	if err != nil {
+		return t, err
+	}
+	return t, nil
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/alexeybs90/go_bus_routes/internal/realtime"
+)
+
+const (
+	gtfsRealtimeVersion = "2.0"
+	gtfsDateLayout      = "20060102"
+	alertLang           = "en"
+	// significantDelay is the delay in seconds from which a trip gets an
+	// alert of its own
+	significantDelay = 10 * 60
+)
+
+// liveTrip is a trip of the published feed, ids are the ones of the GTFS
+// export.
+type liveTrip struct {
+	stops []model.StopTime
+	// start is the first stop of the trip in the frame of its own service
+	// day, stops of a previous day trip begin after midnight only
+	start     model.StopTime
+	dayOffset int
+	// midnight is the start of the service day the trip belongs to
+	midnight time.Time
+}
+
+func (t liveTrip) canceled() bool {
+	for _, st := range t.stops {
+		if !st.Canceled {
+			return false
+		}
+	}
+	return true
+}
+
+// unix converts a time of the stop times frame into a POSIX time.
+func (t liveTrip) unix(sec int) int64 {
+	return t.midnight.Unix() + int64(sec-t.dayOffset*day)
+}
+
+func (t liveTrip) descriptor() realtime.TripDescriptor {
+	return realtime.TripDescriptor{
+		TripId:    strconv.Itoa(t.start.TripId),
+		RouteId:   strconv.Itoa(t.start.RouteId),
+		StartTime: model.FormatClock(t.start.Time),
+		StartDate: t.midnight.Format(gtfsDateLayout),
+	}
+}
+
+func (t liveTrip) entityId(prefix string) string {
+	return prefix + "-" + strconv.Itoa(t.stops[0].TripId) + "-" + t.midnight.Format(gtfsDateLayout)
+}
+
+// liveTrips returns the stored trips running now that have a prediction or
+// a cancellation, ordered by their first departure.
+func (s *busService) liveTrips(ctx context.Context, now time.Time) ([]liveTrip, error) {
+	loc := s.gtfsCfg.Location()
+	now = now.In(loc)
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	stopTimes, err := s.activeStopTimes(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	type tripDay struct {
+		tripId    int
+		dayOffset int
+	}
+	groups := make(map[tripDay][]model.StopTime)
+	live := make(map[tripDay]bool)
+	for _, st := range stopTimes {
+		if st.TripId == 0 {
+			continue
+		}
+		key := tripDay{tripId: st.TripId, dayOffset: st.DayOffset}
+		groups[key] = append(groups[key], st)
+		if st.Predicted != nil || st.Canceled {
+			live[key] = true
+		}
+	}
+
+	var starts map[int]model.StopTime
+	trips := make([]liveTrip, 0, len(live))
+	for key := range live {
+		stops := groups[key]
+		sort.Slice(stops, func(a, b int) bool { return stops[a].Pos < stops[b].Pos })
+		start := stops[0]
+		if key.dayOffset != 0 {
+			if starts == nil {
+				if starts, err = s.tripStarts(ctx); err != nil {
+					return nil, err
+				}
+			}
+			start = starts[key.tripId]
+		}
+		trips = append(trips, liveTrip{
+			stops:     stops,
+			start:     start,
+			dayOffset: key.dayOffset,
+			midnight:  date.AddDate(0, 0, key.dayOffset),
+		})
+	}
+	sort.Slice(trips, func(a, b int) bool {
+		if trips[a].stops[0].Time != trips[b].stops[0].Time {
+			return trips[a].stops[0].Time < trips[b].stops[0].Time
+		}
+		return trips[a].stops[0].TripId < trips[b].stops[0].TripId
+	})
+	return trips, nil
+}
+
+// tripStarts returns the first stop of every stored trip.
+func (s *busService) tripStarts(ctx context.Context) (map[int]model.StopTime, error) {
+	stopTimes, err := s.repository.GetStopTimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	starts := make(map[int]model.StopTime)
+	for _, st := range stopTimes {
+		if first, ok := starts[st.TripId]; st.TripId != 0 && (!ok || st.Pos < first.Pos) {
+			starts[st.TripId] = st
+		}
+	}
+	return starts, nil
+}
+
+func newFeedMessage(now time.Time) *realtime.FeedMessage {
+	return &realtime.FeedMessage{
+		Header: realtime.FeedHeader{
+			Version:        gtfsRealtimeVersion,
+			Incrementality: realtime.FullDataset,
+			Timestamp:      uint64(now.Unix()),
+		},
+		Entities: make([]realtime.FeedEntity, 0),
+	}
+}
+
+// TripUpdatesFeed publishes the predictions of the stored trips. Every stop
+// after the first known one gets its own update, so consumers do not have
+// to carry delays on themselves.
+func (s *busService) TripUpdatesFeed(ctx context.Context) (*realtime.FeedMessage, error) {
+	now := time.Now()
+	trips, err := s.liveTrips(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := newFeedMessage(now)
+	for _, t := range trips {
+		u := &realtime.TripUpdate{Trip: t.descriptor(), Timestamp: uint64(now.Unix())}
+		if t.canceled() {
+			u.Trip.ScheduleRelationship = realtime.ScheduleCanceled
+		} else {
+			for _, st := range t.stops {
+				seq := uint32(st.Pos)
+				update := realtime.StopTimeUpdate{StopSequence: &seq, StopId: strconv.Itoa(st.StationId)}
+				switch {
+				case st.Canceled:
+					update.ScheduleRelationship = realtime.ScheduleSkipped
+				case st.Predicted != nil:
+					delay := int32(*st.Predicted - st.Time)
+					at := t.unix(*st.Predicted)
+					update.Arrival = &realtime.StopTimeEvent{Delay: &delay, Time: &at}
+					update.Departure = &realtime.StopTimeEvent{Delay: &delay, Time: &at}
+				default:
+					continue
+				}
+				u.StopTimeUpdates = append(u.StopTimeUpdates, update)
+			}
+		}
+		msg.Entities = append(msg.Entities, realtime.FeedEntity{Id: t.entityId("trip"), TripUpdate: u})
+	}
+	return msg, nil
+}
+
+// AlertsFeed describes the cancellations, skipped stops and significant
+// delays of the stored trips in plain text.
+func (s *busService) AlertsFeed(ctx context.Context) (*realtime.FeedMessage, error) {
+	now := time.Now()
+	trips, err := s.liveTrips(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := newFeedMessage(now)
+	for _, t := range trips {
+		if a := tripAlert(t); a != nil {
+			msg.Entities = append(msg.Entities, realtime.FeedEntity{Id: t.entityId("alert"), Alert: a})
+		}
+	}
+	return msg, nil
+}
+
+// tripAlert returns nil for a trip that only runs a little late.
+func tripAlert(t liveTrip) *realtime.Alert {
+	first, last := t.stops[0], t.stops[len(t.stops)-1]
+	trip := t.descriptor()
+	subject := fmt.Sprintf("Route %s: the %s trip from %s", t.start.RouteName,
+		model.FormatClock(t.start.Time), t.start.StationName)
+	a := &realtime.Alert{
+		ActivePeriods: []realtime.TimeRange{{
+			Start: uint64(t.unix(first.Time)),
+			End:   uint64(t.unix(last.Time)),
+		}},
+		InformedEntities: []realtime.EntitySelector{{RouteId: trip.RouteId, Trip: &trip}},
+		Cause:            realtime.CauseUnknown,
+	}
+
+	if t.canceled() {
+		a.Effect = realtime.EffectNoService
+		a.HeaderText = translated(subject + " is canceled")
+		return a
+	}
+
+	var skipped []string
+	delay := 0
+	for _, st := range t.stops {
+		switch {
+		case st.Canceled:
+			skipped = append(skipped, st.StationName)
+			a.InformedEntities = append(a.InformedEntities, realtime.EntitySelector{Trip: &trip, StopId: strconv.Itoa(st.StationId)})
+		case st.Predicted != nil:
+			delay = max(delay, *st.Predicted-st.Time)
+			end := uint64(t.unix(*st.Predicted))
+			a.ActivePeriods[0].End = max(a.ActivePeriods[0].End, end)
+		}
+	}
+	switch {
+	case len(skipped) > 0:
+		a.Effect = realtime.EffectModifiedService
+		a.HeaderText = translated(subject + " does not stop at " + strings.Join(skipped, ", "))
+	case delay >= significantDelay:
+		a.Effect = realtime.EffectSignificantDelays
+		a.HeaderText = translated(fmt.Sprintf("%s is running %d min late", subject, delay/60))
+	default:
+		return nil
+	}
+	return a
+}
+
+func translated(text string) realtime.TranslatedString {
+	return realtime.TranslatedString{Translations: []realtime.Translation{{Text: text, Language: alertLang}}}
+}
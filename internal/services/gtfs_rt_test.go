@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+	"github.com/alexeybs90/go_bus_routes/internal/realtime"
+)
+
+// fixedRealtime serves the same trip updates on every call.
+type fixedRealtime map[int]model.TripUpdate
+
+func (r fixedRealtime) TripUpdates() map[int]model.TripUpdate { return r }
+
+func (r fixedRealtime) Vehicles() []model.VehiclePosition { return nil }
+
+func TestTripAlertSkippedStop(t *testing.T) {
+	stops := tripTimes(1, 0, clock(t, "08:00"), 1, 2, 3)
+	stops[1].Canceled = true
+	a := tripAlert(liveTrip{stops: stops, start: stops[0], midnight: time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)})
+	if a == nil || a.Effect != realtime.EffectModifiedService {
+		t.Fatalf("alert %+v, want modified service", a)
+	}
+	if len(a.InformedEntities) != 2 {
+		t.Fatalf("got %d informed entities, want trip and stop", len(a.InformedEntities))
+	}
+	stop := a.InformedEntities[1]
+	if stop.StopId != "2" || stop.Trip == nil || stop.Trip.TripId != "100" || stop.Trip.StartDate != "20250304" {
+		t.Errorf("skipped stop selector %+v, trip %+v", stop, stop.Trip)
+	}
+}
+
+func TestLiveTripsOvernightStart(t *testing.T) {
+	// 23:40 at station 1 until 24:20 at station 5, canceled yesterday
+	s := &busService{repository: &scheduleRepository{stopTimes: tripTimes(1, 0, clock(t, "23:40"), 1, 2, 3, 4, 5)}}
+	s.SetRealtime(fixedRealtime{100: {TripId: 100, StartDate: "2025-03-04", Canceled: true}})
+
+	trips, err := s.liveTrips(context.Background(), time.Date(2025, 3, 5, 0, 15, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trips) != 1 || trips[0].dayOffset != -1 {
+		t.Fatalf("live trips %+v, want the trip of the previous day", trips)
+	}
+	trip := trips[0].descriptor()
+	if trip.StartTime != "23:40:00" || trip.StartDate != "20250304" {
+		t.Errorf("descriptor starts %s %s, want 23:40:00 20250304", trip.StartTime, trip.StartDate)
+	}
+	a := tripAlert(trips[0])
+	if a == nil || !strings.Contains(a.HeaderText.Translations[0].Text, "the 23:40:00 trip") {
+		t.Errorf("alert %+v, want the trip named by its first departure", a)
+	}
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+	"io"
+
+	"github.com/alexeybs90/go_bus_routes/internal/netex"
+)
+
+// ExportNeTEx writes the stored trips as a NeTEx PublicationDelivery, the
+// same data the GTFS export holds.
+func (s *busService) ExportNeTEx(ctx context.Context, w io.Writer) error {
+	t, err := s.gtfsTimetable(ctx)
+	if err != nil {
+		return err
+	}
+	routeStations, err := s.repository.GetRouteStations(ctx)
+	if err != nil {
+		return err
+	}
+	return netex.Write(w, s.gtfsCfg, netex.Timetable{
+		Stations:      t.Stations,
+		Routes:        t.Routes,
+		RouteStations: routeStations,
+		Calendars:     t.Calendars,
+		StopTimes:     t.StopTimes,
+		Start:         t.Start,
+	})
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+type network struct {
+	routes    map[int][]model.RouteStation
+	byStation map[int][]model.RouteStation
+	footpaths map[int][]model.Transfer
+	avoided   map[int]bool
+	// blocked stations can be ridden through but not boarded or left
+	blocked map[int]bool
+}
+
+func newNetwork(routeStations []model.RouteStation, transfers []model.Transfer) *network {
+	n := &network{
+		routes:    make(map[int][]model.RouteStation),
+		byStation: make(map[int][]model.RouteStation),
+		footpaths: make(map[int][]model.Transfer),
+		avoided:   make(map[int]bool),
+	}
+	for _, rs := range routeStations {
+		n.routes[rs.RouteId] = append(n.routes[rs.RouteId], rs)
+		n.byStation[rs.StationId] = append(n.byStation[rs.StationId], rs)
+	}
+	for _, stops := range n.routes {
+		sort.Slice(stops, func(i, j int) bool { return stops[i].Pos < stops[j].Pos })
+	}
+	for _, t := range transfers {
+		n.footpaths[t.FromId] = append(n.footpaths[t.FromId], t)
+	}
+	return n
+}
+
+// findJourneys returns every itinerary with the fewest legs that does not
+// need more than q.MaxTransfers changes. Walking transfers are not counted
+// as legs.
+func (n *network) findJourneys(q model.JourneyQuery) []model.Journey {
+	if len(q.ViaIds) > 0 {
+		return n.findJourneysVia(q)
+	}
+
+	journeys := make([]model.Journey, 0)
+	if q.FromId == q.ToId {
+		return journeys
+	}
+
+	for legs := 1; legs <= q.MaxTransfers+1 && len(journeys) == 0; legs++ {
+		seen := map[int]bool{q.FromId: true}
+		used := map[int]bool{}
+		n.walk(q.FromId, q.ToId, legs, seen, used, nil, &journeys)
+	}
+
+	sort.SliceStable(journeys, func(i, j int) bool {
+		return stopCount(journeys[i]) < stopCount(journeys[j])
+	})
+	return journeys
+}
+
+// walk boards at the station itself or at a station one walking transfer
+// away from it.
+func (n *network) walk(at, to, legsLeft int, seen, used map[int]bool, path []model.Leg, found *[]model.Journey) {
+	n.ride(at, to, legsLeft, seen, used, path, found)
+	for _, t := range n.footpaths[at] {
+		if t.ToId == to || seen[t.ToId] || n.avoided[t.ToId] || n.blocked[t.ToId] {
+			continue
+		}
+		seen[t.ToId] = true
+		n.ride(t.ToId, to, legsLeft, seen, used, append(path, newWalkLeg(t)), found)
+		seen[t.ToId] = false
+	}
+}
+
+func (n *network) ride(at, to, legsLeft int, seen, used map[int]bool, path []model.Leg, found *[]model.Journey) {
+	for _, board := range n.byStation[at] {
+		if used[board.RouteId] || n.avoided[board.StationId] || n.blocked[board.StationId] {
+			continue
+		}
+		for _, alight := range n.routes[board.RouteId] {
+			if alight.Pos <= board.Pos || seen[alight.StationId] {
+				continue
+			}
+			if n.avoided[alight.StationId] {
+				break
+			}
+			if n.blocked[alight.StationId] {
+				continue
+			}
+			leg := model.Leg{
+				RouteId:   board.RouteId,
+				RouteName: board.RouteName,
+				Board:     model.Place{Id: board.StationId, Name: board.StationName},
+				Alight:    model.Place{Id: alight.StationId, Name: alight.StationName},
+				Stops:     alight.Pos - board.Pos,
+			}
+			if alight.StationId == to {
+				if legsLeft == 1 {
+					*found = append(*found, newJourney(append(path, leg)))
+				}
+				continue
+			}
+			if legsLeft == 1 {
+				for _, t := range n.footpaths[alight.StationId] {
+					if t.ToId == to {
+						*found = append(*found, newJourney(append(path, leg, newWalkLeg(t))))
+					}
+				}
+				continue
+			}
+			if len(n.byStation[alight.StationId]) < 2 && len(n.footpaths[alight.StationId]) == 0 {
+				continue
+			}
+			seen[alight.StationId] = true
+			used[board.RouteId] = true
+			n.walk(alight.StationId, to, legsLeft-1, seen, used, append(path, leg), found)
+			seen[alight.StationId] = false
+			used[board.RouteId] = false
+		}
+	}
+}
+
+func (n *network) avoid(stationIds []int) {
+	for _, id := range stationIds {
+		n.avoided[id] = true
+	}
+}
+
+func newWalkLeg(t model.Transfer) model.Leg {
+	return model.Leg{
+		Walk:        true,
+		Board:       model.Place{Id: t.FromId, Name: t.FromName},
+		Alight:      model.Place{Id: t.ToId, Name: t.ToName},
+		WalkMinutes: t.WalkMinutes,
+	}
+}
+
+// newJourney links every ride to the place of the next change, walks are
+// part of a change and are not counted as transfers.
+func newJourney(path []model.Leg) model.Journey {
+	legs := make([]model.Leg, len(path))
+	copy(legs, path)
+	rides := 0
+	for i := len(legs) - 1; i >= 0; i-- {
+		if legs[i].Walk {
+			continue
+		}
+		if rides > 0 {
+			next := legs[i+1].Board
+			legs[i].Transfer = &next
+		}
+		rides++
+	}
+	journey := model.Journey{
+		Legs:      legs,
+		Transfers: max(rides-1, 0),
+	}
+	if len(legs) > 0 {
+		journey.Departure = legs[0].Departure
+		journey.Arrival = legs[len(legs)-1].Arrival
+	}
+	return journey
+}
+
+func stopCount(j model.Journey) int {
+	count := 0
+	for _, leg := range j.Legs {
+		count += leg.Stops
+	}
+	return count
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+const (
+	labelFastest         = "fastest"
+	labelEarliestArrival = "earliest arrival"
+	labelLatestDeparture = "latest departure"
+	labelFewestChanges   = "fewest changes"
+	labelLeastWaiting    = "least waiting"
+)
+
+// option is a candidate itinerary together with the criteria it is
+// compared on.
+type option struct {
+	rides     []ride
+	departure int
+	arrival   int
+	transfers int
+	wait      int
+}
+
+func newOption(rides []ride) option {
+	// a walk from the origin starts just in time for the first trip and a
+	// walk to the destination right after the last one
+	if n := len(rides); n > 1 {
+		if first := &rides[0]; first.walk {
+			shift := rides[1].board.Time - first.alight.Time
+			first.board.Time += shift
+			first.alight.Time += shift
+		}
+		if last := &rides[n-1]; last.walk {
+			shift := rides[n-2].alight.Time - last.board.Time
+			last.board.Time += shift
+			last.alight.Time += shift
+		}
+	}
+	o := option{
+		rides:     rides,
+		departure: rides[0].board.Time,
+		arrival:   rides[len(rides)-1].alight.Time,
+	}
+	for i, r := range rides {
+		if !r.walk {
+			o.transfers++
+		}
+		if i > 0 {
+			o.wait += r.board.Time - rides[i-1].alight.Time
+		}
+	}
+	o.transfers = max(o.transfers-1, 0)
+	return o
+}
+
+func (o option) key() string {
+	key := ""
+	for _, r := range o.rides {
+		key += fmt.Sprintf("%d/%d/%d/%d/%d/%t;", r.board.RouteId, r.board.FrequencyId, r.board.Queue,
+			r.board.StationId, r.alight.StationId, r.walk)
+	}
+	return key
+}
+
+// earliestArrival searches from depart_at and from every later departure at
+// the origin until the fastest arrival, so itineraries with fewer changes or
+// shorter waits are found next to the fastest one.
+func (tt *timetable) earliestArrival(q model.JourneyQuery) []model.Journey {
+	if len(q.ViaIds) > 0 {
+		return tt.earliestArrivalVia(q)
+	}
+	options := tt.forwardOptions(q.FromId, q.ToId, *q.DepartAt, q.MaxTransfers+1)
+	return labelledJourneys(paretoFront(options, byArrival), labelEarliestArrival)
+}
+
+// latestDeparture is the arrive_by counterpart of earliestArrival.
+func (tt *timetable) latestDeparture(q model.JourneyQuery) []model.Journey {
+	if len(q.ViaIds) > 0 {
+		return tt.latestDepartureVia(q)
+	}
+	options := tt.backwardOptions(q.FromId, q.ToId, *q.ArriveBy, q.MaxTransfers+1)
+	return labelledJourneys(paretoFront(options, byDeparture), labelLatestDeparture)
+}
+
+func (tt *timetable) forwardOptions(fromId, toId, departAt, maxLegs int) []option {
+	if fromId == toId {
+		return nil
+	}
+
+	var options []option
+	collect := func(departAt int) {
+		rounds := tt.scan(fromId, departAt, math.MaxInt, maxLegs)
+		for k := 1; k < len(rounds); k++ {
+			if l, ok := rounds[k][toId]; ok && l.round == k {
+				options = append(options, newOption(tt.ridesForward(rounds, toId, k)))
+			}
+		}
+	}
+
+	collect(departAt)
+	if len(options) == 0 {
+		return nil
+	}
+	fastest := options[0].arrival
+	for _, o := range options {
+		fastest = min(fastest, o.arrival)
+	}
+	for _, t := range tt.departureTimes(fromId, departAt, fastest) {
+		collect(t)
+	}
+	return options
+}
+
+func (tt *timetable) backwardOptions(fromId, toId, arriveBy, maxLegs int) []option {
+	if fromId == toId {
+		return nil
+	}
+
+	var options []option
+	collect := func(arriveBy int) {
+		rounds := tt.scanBackward(toId, arriveBy, maxLegs)
+		for k := 1; k < len(rounds); k++ {
+			if l, ok := rounds[k][fromId]; ok && l.round == k {
+				options = append(options, newOption(tt.ridesBackward(rounds, fromId, k)))
+			}
+		}
+	}
+
+	collect(arriveBy)
+	if len(options) == 0 {
+		return nil
+	}
+	latest := options[0].departure
+	for _, o := range options {
+		latest = max(latest, o.departure)
+	}
+	for _, t := range tt.arrivalTimes(toId, latest, arriveBy) {
+		collect(t)
+	}
+	return options
+}
+
+// departureTimes lists distinct departures from the station strictly
+// between from and until.
+func (tt *timetable) departureTimes(stationId, from, until int) []int {
+	seen := make(map[int]bool)
+	times := make([]int, 0)
+	for _, c := range tt.connections {
+		if c.from.StationId == stationId && c.from.Time > from && c.from.Time < until && !seen[c.from.Time] {
+			seen[c.from.Time] = true
+			times = append(times, c.from.Time)
+		}
+	}
+	sort.Ints(times)
+	return times
+}
+
+// arrivalTimes lists distinct arrivals at the station strictly between from
+// and until.
+func (tt *timetable) arrivalTimes(stationId, from, until int) []int {
+	seen := make(map[int]bool)
+	times := make([]int, 0)
+	for _, c := range tt.connections {
+		if c.to.StationId == stationId && c.to.Time > from && c.to.Time < until && !seen[c.to.Time] {
+			seen[c.to.Time] = true
+			times = append(times, c.to.Time)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(times)))
+	return times
+}
+
+func byArrival(o option) int {
+	return o.arrival
+}
+
+func byDeparture(o option) int {
+	return -o.departure
+}
+
+// paretoFront keeps the options no other option beats on time, transfers
+// and wait at once, best primary time first.
+func paretoFront(options []option, primary func(option) int) []option {
+	seen := make(map[string]bool)
+	unique := make([]option, 0, len(options))
+	for _, o := range options {
+		if !seen[o.key()] {
+			seen[o.key()] = true
+			unique = append(unique, o)
+		}
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		a, b := unique[i], unique[j]
+		switch {
+		case primary(a) != primary(b):
+			return primary(a) < primary(b)
+		case a.transfers != b.transfers:
+			return a.transfers < b.transfers
+		case a.wait != b.wait:
+			return a.wait < b.wait
+		}
+		return a.arrival-a.departure < b.arrival-b.departure
+	})
+
+	front := make([]option, 0)
+	for _, o := range unique {
+		dominated := false
+		for _, f := range front {
+			if primary(f) <= primary(o) && f.transfers <= o.transfers && f.wait <= o.wait {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			front = append(front, o)
+		}
+	}
+	return front
+}
+
+// labelledJourneys turns a Pareto front into journeys and labels the best
+// one for each criterion, the first one of the front gets primary. Fastest
+// is the shortest time from departure to arrival, in arrive_by mode that is
+// not the latest departure.
+func labelledJourneys(front []option, primary string) []model.Journey {
+	fastest, fewest, least := 0, 0, 0
+	for i, o := range front {
+		if o.arrival-o.departure < front[fastest].arrival-front[fastest].departure {
+			fastest = i
+		}
+		if o.transfers < front[fewest].transfers {
+			fewest = i
+		}
+		if o.wait < front[least].wait {
+			least = i
+		}
+	}
+
+	journeys := make([]model.Journey, 0, len(front))
+	for i, o := range front {
+		legs := make([]model.Leg, 0, len(o.rides))
+		for _, r := range o.rides {
+			legs = append(legs, newTimedLeg(r))
+		}
+		journey := newJourney(legs)
+		journey.WaitMinutes = o.wait / 60
+		if i == 0 {
+			journey.Labels = append(journey.Labels, primary)
+		}
+		if i == fastest {
+			journey.Labels = append(journey.Labels, labelFastest)
+		}
+		if i == fewest {
+			journey.Labels = append(journey.Labels, labelFewestChanges)
+		}
+		if i == least {
+			journey.Labels = append(journey.Labels, labelLeastWaiting)
+		}
+		journeys = append(journeys, journey)
+	}
+	return journeys
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// testOption is an option on its own route, so options never share a key.
+func testOption(routeId, departure, arrival, transfers, wait int) option {
+	return option{
+		rides: []ride{{
+			board:  model.StopTime{RouteId: routeId, StationId: 1, Time: departure},
+			alight: model.StopTime{RouteId: routeId, StationId: 2, Time: arrival},
+		}},
+		departure: departure,
+		arrival:   arrival,
+		transfers: transfers,
+		wait:      wait,
+	}
+}
+
+func routeIds(front []option) []int {
+	ids := make([]int, 0, len(front))
+	for _, o := range front {
+		ids = append(ids, o.rides[0].board.RouteId)
+	}
+	return ids
+}
+
+func TestParetoFront(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []option
+		primary func(option) int
+		want    []int
+	}{
+		{
+			name: "dominated option dropped",
+			options: []option{
+				testOption(1, 100, 200, 1, 10),
+				testOption(2, 100, 250, 2, 20),
+			},
+			primary: byArrival,
+			want:    []int{1},
+		},
+		{
+			name: "trade offs kept, earliest arrival first",
+			options: []option{
+				testOption(1, 100, 300, 0, 0),
+				testOption(2, 100, 200, 2, 60),
+				testOption(3, 100, 250, 1, 30),
+			},
+			primary: byArrival,
+			want:    []int{2, 3, 1},
+		},
+		{
+			name: "latest departure first",
+			options: []option{
+				testOption(1, 100, 400, 0, 0),
+				testOption(2, 200, 400, 1, 30),
+			},
+			primary: byDeparture,
+			want:    []int{2, 1},
+		},
+		{
+			name: "duplicates removed",
+			options: []option{
+				testOption(1, 100, 200, 0, 0),
+				testOption(1, 100, 200, 0, 0),
+			},
+			primary: byArrival,
+			want:    []int{1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := routeIds(paretoFront(tc.options, tc.primary)); !slices.Equal(got, tc.want) {
+				t.Errorf("front %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLabelledJourneys(t *testing.T) {
+	labels := func(journeys []model.Journey) [][]string {
+		out := make([][]string, 0, len(journeys))
+		for _, j := range journeys {
+			out = append(out, j.Labels)
+		}
+		return out
+	}
+
+	t.Run("arrive_by fastest is the shortest trip", func(t *testing.T) {
+		// leaving at 200 arrives at 400 after one change, leaving at 150 on
+		// a direct trip arrives at 250
+		front := paretoFront([]option{
+			testOption(1, 200, 400, 1, 30),
+			testOption(2, 150, 250, 0, 0),
+		}, byDeparture)
+		got := labels(labelledJourneys(front, labelLatestDeparture))
+		want := [][]string{
+			{labelLatestDeparture},
+			{labelFastest, labelFewestChanges, labelLeastWaiting},
+		}
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("labels %v, want %v", got, want)
+		}
+	})
+
+	t.Run("single option gets every label", func(t *testing.T) {
+		got := labels(labelledJourneys([]option{testOption(1, 100, 200, 0, 0)}, labelEarliestArrival))
+		want := [][]string{{labelEarliestArrival, labelFastest, labelFewestChanges, labelLeastWaiting}}
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("labels %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+func (s *busService) GetReachable(ctx context.Context, fromId int, date time.Time, departAt int, budget int) ([]model.Reachable, error) {
+	stopTimes, err := s.activeStopTimes(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	transfers, err := s.repository.GetTransfers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newTimetable(stopTimes, transfers).reachable(fromId, departAt, budget, s.cfg.MaxTransfers+1), nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// Realtime is the source of trip updates and vehicle positions.
+type Realtime interface {
+	TripUpdates() map[int]model.TripUpdate
+	Vehicles() []model.VehiclePosition
+}
+
+// SetRealtime adds predicted times to departures and journeys, without it
+// the service works on the schedule only.
+func (s *busService) SetRealtime(rt Realtime) {
+	s.realtime = rt
+}
+
+func (s *busService) GetVehicles(ctx context.Context) ([]model.VehiclePosition, error) {
+	if s.realtime == nil {
+		return make([]model.VehiclePosition, 0), nil
+	}
+	return s.realtime.Vehicles(), nil
+}
+
+// withRealtime sets the predictions of the stop times whose trip has an
+// update for the service day the stop time belongs to.
+func (s *busService) withRealtime(stopTimes []model.StopTime, date time.Time) {
+	if s.realtime == nil {
+		return
+	}
+	updates := s.realtime.TripUpdates()
+	if len(updates) == 0 {
+		return
+	}
+
+	type tripDay struct {
+		tripId    int
+		dayOffset int
+	}
+	trips := make(map[tripDay][]int)
+	for i, st := range stopTimes {
+		if _, ok := updates[st.TripId]; ok && st.TripId != 0 {
+			key := tripDay{tripId: st.TripId, dayOffset: st.DayOffset}
+			trips[key] = append(trips[key], i)
+		}
+	}
+	for key, idx := range trips {
+		u := updates[key.tripId]
+		if u.StartDate != date.AddDate(0, 0, key.dayOffset).Format(model.DateLayout) {
+			continue
+		}
+		predictTrip(stopTimes, idx, u, key.dayOffset*day)
+	}
+}
+
+// predictTrip walks the stops of one trip in order, every stop update sets
+// the delay carried on to the following stops. shift moves the update
+// times into the frame of stop times taken from the previous service day.
+func predictTrip(stopTimes []model.StopTime, idx []int, u model.TripUpdate, shift int) {
+	sort.Slice(idx, func(a, b int) bool { return stopTimes[idx[a]].Pos < stopTimes[idx[b]].Pos })
+	byPos := make(map[int]model.StopUpdate, len(u.Stops))
+	for _, su := range u.Stops {
+		if su.Pos >= 0 {
+			byPos[su.Pos] = su
+			continue
+		}
+		for _, i := range idx {
+			if stopTimes[i].StationId == su.StationId {
+				byPos[stopTimes[i].Pos] = su
+			}
+		}
+	}
+
+	delay, known := 0, u.Delay != nil
+	if known {
+		delay = *u.Delay
+	}
+	for _, i := range idx {
+		st := &stopTimes[i]
+		if u.Canceled {
+			st.Canceled = true
+			continue
+		}
+		if su, ok := byPos[st.Pos]; ok {
+			switch {
+			case su.Skipped:
+				st.Canceled = true
+				continue
+			case su.Time != nil:
+				delay, known = *su.Time+shift-st.Time, true
+			case su.Delay != nil:
+				delay, known = *su.Delay, true
+			}
+		}
+		if known {
+			predicted := st.Time + delay
+			st.Predicted = &predicted
+		}
+	}
+}
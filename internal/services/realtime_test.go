@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+func TestPredictTrip(t *testing.T) {
+	h := func(v string) int { return clock(t, v) }
+	ptr := func(v int) *int { return &v }
+	tests := []struct {
+		name  string
+		u     model.TripUpdate
+		shift int
+		// predicted times by pos, "" for no prediction and "x" for canceled
+		want []string
+	}{
+		{
+			name: "delay carried to the following stops",
+			u:    model.TripUpdate{Stops: []model.StopUpdate{{Pos: 1, Delay: ptr(120)}}},
+			want: []string{"", "08:12:00", "08:22:00", "08:32:00"},
+		},
+		{
+			name: "trip delay until the first stop update",
+			u: model.TripUpdate{Delay: ptr(60), Stops: []model.StopUpdate{
+				{Pos: 2, Time: ptr(h("08:25"))},
+			}},
+			want: []string{"08:01:00", "08:11:00", "08:25:00", "08:35:00"},
+		},
+		{
+			name: "update by station",
+			u:    model.TripUpdate{Stops: []model.StopUpdate{{Pos: -1, StationId: 3, Delay: ptr(-60)}}},
+			want: []string{"", "", "08:19:00", "08:29:00"},
+		},
+		{
+			name: "skipped stop",
+			u: model.TripUpdate{Stops: []model.StopUpdate{
+				{Pos: 0, Delay: ptr(300)},
+				{Pos: 2, Skipped: true},
+			}},
+			want: []string{"08:05:00", "08:15:00", "x", "08:35:00"},
+		},
+		{
+			name: "canceled trip",
+			u:    model.TripUpdate{Canceled: true, Delay: ptr(60)},
+			want: []string{"x", "x", "x", "x"},
+		},
+		{
+			name:  "times of the previous service day",
+			u:     model.TripUpdate{Stops: []model.StopUpdate{{Pos: 1, Time: ptr(h("32:15"))}}},
+			shift: -day,
+			want:  []string{"", "08:15:00", "08:25:00", "08:35:00"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stopTimes := tripTimes(1, 0, h("08:00"), 1, 2, 3, 4)
+			// stops out of order, as the timetable query returns them
+			stopTimes[0], stopTimes[3] = stopTimes[3], stopTimes[0]
+			idx := []int{0, 1, 2, 3}
+			predictTrip(stopTimes, idx, tc.u, tc.shift)
+
+			got := make([]string, len(stopTimes))
+			for _, st := range stopTimes {
+				switch {
+				case st.Canceled:
+					got[st.Pos] = "x"
+				case st.Predicted != nil:
+					got[st.Pos] = model.FormatClock(*st.Predicted)
+				}
+			}
+			for pos := range tc.want {
+				if got[pos] != tc.want[pos] {
+					t.Errorf("predicted %q, want %q", got, tc.want)
+					break
+				}
+			}
+		})
+	}
+}
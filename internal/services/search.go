@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// minSearchScore drops matches that share little more than a few letters
+// with the query.
+const minSearchScore = 0.6
+
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+// stationIndex holds the search terms of every station name and the
+// trigrams of those terms, a search only scores the stations sharing a
+// trigram with the query. It is rebuilt when the stations version changes.
+type stationIndex struct {
+	version  int64
+	stations []*model.Station
+	// names holds the terms of every name of the station at the same index
+	names [][][][]rune
+	grams map[string][]int
+}
+
+func newStationIndex(version int64, items []model.Model) *stationIndex {
+	idx := &stationIndex{version: version, grams: make(map[string][]int)}
+	for _, item := range items {
+		st, ok := item.(*model.Station)
+		if !ok {
+			continue
+		}
+		i := len(idx.stations)
+		names := [][][]rune{searchTerms(st.Name)}
+		for _, name := range st.Translations {
+			names = append(names, searchTerms(name))
+		}
+		seen := make(map[string]bool)
+		for _, terms := range names {
+			for _, term := range terms {
+				for _, g := range trigrams(term) {
+					if !seen[g] {
+						seen[g] = true
+						idx.grams[g] = append(idx.grams[g], i)
+					}
+				}
+			}
+		}
+		idx.stations = append(idx.stations, st)
+		idx.names = append(idx.names, names)
+	}
+	return idx
+}
+
+// candidates returns the indexes of the stations sharing a trigram with
+// one of the terms.
+func (idx *stationIndex) candidates(terms [][]rune) []int {
+	seen := make(map[int]bool)
+	found := make([]int, 0)
+	for _, term := range terms {
+		for _, g := range trigrams(term) {
+			for _, i := range idx.grams[g] {
+				if !seen[i] {
+					seen[i] = true
+					found = append(found, i)
+				}
+			}
+		}
+	}
+	return found
+}
+
+// trigrams pads the term like pg_trgm does, so one and two letter prefixes
+// still have trigrams of their own.
+func trigrams(term []rune) []string {
+	padded := make([]rune, 0, len(term)+3)
+	padded = append(padded, ' ', ' ')
+	padded = append(padded, term...)
+	padded = append(padded, ' ')
+	grams := make([]string, 0, len(padded)-2)
+	for i := 0; i+3 <= len(padded); i++ {
+		grams = append(grams, string(padded[i:i+3]))
+	}
+	return grams
+}
+
+// stationIndex returns the search index of the current stations, a cheap
+// version check decides whether the stored one is still valid.
+func (s *busService) stationIndex(ctx context.Context) (*stationIndex, error) {
+	version, err := s.repository.GetStationsVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.searchMu.Lock()
+	defer s.searchMu.Unlock()
+	if s.search != nil && s.search.version == version {
+		return s.search, nil
+	}
+	stations, err := s.repository.GetStations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.search = newStationIndex(version, stations)
+	return s.search, nil
+}
+
+// SearchStations ranks stations by how close their names are to the query,
+// ignoring case, punctuation, typos and Cyrillic vs Latin spelling.
+func (s *busService) SearchStations(ctx context.Context, query string, limit int) ([]model.StationMatch, error) {
+	terms := searchTerms(query)
+	items := make([]model.StationMatch, 0)
+	if len(terms) == 0 {
+		return items, nil
+	}
+
+	idx, err := s.stationIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range idx.candidates(terms) {
+		score := 0.0
+		for _, name := range idx.names[i] {
+			score = max(score, matchScore(terms, name))
+		}
+		if score < minSearchScore {
+			continue
+		}
+		items = append(items, model.StationMatch{Station: *idx.stations[i], Score: math.Round(score*1000) / 1000})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		return items[i].Name < items[j].Name
+	})
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// searchTerms lowercases and transliterates the text into Latin words, kept
+// as runes so letters outside the table are compared whole.
+func searchTerms(text string) [][]rune {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if t, ok := translit[r]; ok {
+			sb.WriteString(t)
+		} else if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune(' ')
+		}
+	}
+	fields := strings.Fields(sb.String())
+	terms := make([][]rune, 0, len(fields))
+	for _, f := range fields {
+		terms = append(terms, []rune(f))
+	}
+	return terms
+}
+
+// matchScore averages the best match of every query term among the name
+// words.
+func matchScore(query, name [][]rune) float64 {
+	if len(name) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, q := range query {
+		best := 0.0
+		for _, w := range name {
+			best = max(best, termScore(q, w))
+		}
+		total += best
+	}
+	return total / float64(len(query))
+}
+
+func termScore(q, w []rune) float64 {
+	switch {
+	case slices.Equal(q, w):
+		return 1
+	case len(w) > len(q) && slices.Equal(w[:len(q)], q):
+		return 0.9 + 0.05*float64(len(q))/float64(len(w))
+	}
+	score := similarity(q, w)
+	if len(w) > len(q) {
+		// partial words with a typo, "kupchni" for "kupchino"
+		score = max(score, 0.85*similarity(q, w[:len(q)]))
+	}
+	return score
+}
+
+// similarity is 1 minus the edit distance relative to the longer word.
+func similarity(a, b []rune) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
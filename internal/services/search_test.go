@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"kupchino", "kupchino", 0},
+		{"kupchino", "kupchini", 1},
+		{"große", "grosse", 2},
+		{"αθήνα", "αθηνα", 1},
+	}
+	for _, tc := range tests {
+		if got := levenshtein([]rune(tc.a), []rune(tc.b)); got != tc.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestMatchScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		text  string
+		min   float64
+	}{
+		{name: "same name", query: "Купчино", text: "Купчино", min: 1},
+		{name: "transliterated", query: "kupchino", text: "Купчино", min: 1},
+		{name: "prefix", query: "kupch", text: "Купчино", min: 0.9},
+		{name: "typo in prefix", query: "kupchni", text: "Купчино", min: minSearchScore},
+		{name: "multibyte prefix", query: "Αθή", text: "Αθήνα", min: 0.9},
+		{name: "multibyte typo in prefix", query: "grüß", text: "Größe", min: minSearchScore},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := matchScore(searchTerms(tc.query), searchTerms(tc.text)); got < tc.min {
+				t.Errorf("score %.3f, want at least %.3f", got, tc.min)
+			}
+		})
+	}
+}
+
+// searchRepository counts the station loads, version is bumped by the test
+// to stand for a write.
+type searchRepository struct {
+	model.Repository
+	stations []model.Model
+	version  int64
+	loads    int
+}
+
+func (r *searchRepository) GetStationsVersion(context.Context) (int64, error) {
+	return r.version, nil
+}
+
+func (r *searchRepository) GetStations(context.Context) ([]model.Model, error) {
+	r.loads++
+	return r.stations, nil
+}
+
+func TestSearchStations(t *testing.T) {
+	repo := &searchRepository{stations: []model.Model{
+		&model.Station{Id: 1, Name: "м. Купчино", Translations: model.Translations{"en": "Kupchino metro"}},
+		&model.Station{Id: 2, Name: "м. Московская"},
+		&model.Station{Id: 3, Name: "Невский проспект"},
+	}}
+	s := &busService{repository: repo}
+	search := func(query string) []int {
+		t.Helper()
+		items, err := s.SearchStations(context.Background(), query, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]int, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.Id)
+		}
+		return ids
+	}
+
+	if ids := search("kupchni"); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("kupchni found %v, want station 1", ids)
+	}
+	if ids := search("Мос"); len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("Мос found %v, want station 2", ids)
+	}
+	if ids := search("metro"); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("metro found %v, want station 1 by its translation", ids)
+	}
+	if repo.loads != 1 {
+		t.Errorf("stations loaded %d times for one version, want once", repo.loads)
+	}
+
+	repo.stations[2] = &model.Station{Id: 3, Name: "Купчино-2"}
+	repo.version++
+	if ids := search("kupchino"); len(ids) != 2 {
+		t.Errorf("kupchino found %v after the rename, want stations 1 and 3", ids)
+	}
+	if ids := search("nevsky"); len(ids) != 0 {
+		t.Errorf("nevsky found %v after the rename", ids)
+	}
+	if repo.loads != 2 {
+		t.Errorf("stations loaded %d times, want a reload after the version change", repo.loads)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	idx := newStationIndex(1, []model.Model{
+		&model.Station{Id: 1, Name: "Kupchino"},
+		&model.Station{Id: 2, Name: "Zvezdnaya"},
+	})
+	if got := idx.candidates(searchTerms("k")); len(got) != 1 || idx.stations[got[0]].Id != 1 {
+		t.Errorf("candidates of k %v", got)
+	}
+	if got := idx.candidates(searchTerms("moskva")); len(got) != 0 {
+		t.Errorf("candidates of moskva %v, want none", got)
+	}
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"sync"
 
+	"github.com/alexeybs90/go_bus_routes/internal/config"
 	"github.com/alexeybs90/go_bus_routes/internal/model"
 	"github.com/alexeybs90/go_bus_routes/pkg/logger"
 )
@@ -10,15 +12,85 @@ import (
 type busService struct {
 	repository model.Repository
 	logger     logger.Logger
+	cfg        config.Journey
+	fareCfg    config.Fare
+	gtfsCfg    config.GTFS
+	realtime   Realtime
+	// search is the station search index, guarded by searchMu
+	searchMu sync.Mutex
+	search   *stationIndex
 }
 
-func New(rep model.Repository, log logger.Logger) *busService {
+func New(rep model.Repository, log logger.Logger, cfg config.Journey, fareCfg config.Fare, gtfsCfg config.GTFS) *busService {
 	return &busService{
 		repository: rep,
 		logger:     log,
+		cfg:        cfg,
+		fareCfg:    fareCfg,
+		gtfsCfg:    gtfsCfg,
 	}
 }
 
-func (s *busService) FindBus(ctx context.Context, fromId int, toId int) ([]model.Model, error) {
-	return s.repository.FindBus(ctx, fromId, toId)
+func (s *busService) FindBus(ctx context.Context, q model.JourneyQuery) ([]model.Journey, error) {
+	if q.MaxTransfers < 0 || q.MaxTransfers > s.cfg.MaxTransfers {
+		q.MaxTransfers = s.cfg.MaxTransfers
+	}
+
+	transfers, err := s.repository.GetTransfers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocked map[int]bool
+	if q.Wheelchair {
+		blocked, err = s.inaccessibleStations(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var journeys []model.Journey
+	if q.DepartAt != nil || q.ArriveBy != nil {
+		stopTimes, err := s.activeStopTimes(ctx, q.Date)
+		if err != nil {
+			return nil, err
+		}
+		tt := newTimetable(stopTimes, transfers)
+		tt.avoid(q.AvoidIds)
+		if q.Wheelchair {
+			tt.stepFree(blocked)
+		}
+		if q.ArriveBy != nil {
+			journeys = tt.latestDeparture(q)
+		} else {
+			journeys = tt.earliestArrival(q)
+		}
+	} else {
+		routeStations, err := s.repository.GetRouteStations(ctx)
+		if err != nil {
+			return nil, err
+		}
+		n := newNetwork(routeStations, transfers)
+		n.avoid(q.AvoidIds)
+		if q.Wheelchair {
+			routes, err := s.accessibleRoutes(ctx)
+			if err != nil {
+				return nil, err
+			}
+			n.stepFree(blocked, routes)
+		}
+		journeys = n.findJourneys(q)
+	}
+
+	if len(journeys) == 0 {
+		return journeys, nil
+	}
+	ft, err := s.loadFares(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range journeys {
+		journeys[i].Fare = ft.journeyFare(journeys[i])
+	}
+	return journeys, nil
 }
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+type tripKey struct {
+	routeId     int
+	frequencyId int
+	queue       int
+	dayOffset   int
+	// segment counts the avoided stops the trip passed, see avoid
+	segment int
+}
+
+type connection struct {
+	trip tripKey
+	from model.StopTime
+	to   model.StopTime
+}
+
+// ride is one leg of an itinerary, walk rides only carry station and time
+// in board and alight.
+type ride struct {
+	board  model.StopTime
+	alight model.StopTime
+	walk   bool
+}
+
+type footpath struct {
+	from int
+	to   int
+	// walk in seconds
+	walk int
+}
+
+type timetable struct {
+	connections []connection
+	footpaths   map[int][]footpath
+	// footpathsTo indexes the same footpaths by their destination
+	footpathsTo map[int][]footpath
+	names       map[int]string
+	// blocked stations can be ridden through but not boarded or left
+	blocked map[int]bool
+}
+
+// label is the best known way to reach a station within a scan round: the
+// earliest arrival for forward scans and the latest departure for backward
+// ones. board and alight point into timetable.connections, both are -1 for
+// the station the scan started from. walk is the station at the other end
+// of a walking transfer of walkTime seconds, 0 when there is no walk; board
+// and alight then describe the ride at that other station.
+type label struct {
+	time     int
+	round    int
+	board    int
+	alight   int
+	walk     int
+	walkTime int
+}
+
+func newTimetable(stopTimes []model.StopTime, transfers []model.Transfer) *timetable {
+	tt := &timetable{
+		footpaths:   make(map[int][]footpath),
+		footpathsTo: make(map[int][]footpath),
+		names:       make(map[int]string),
+	}
+
+	trips := make(map[tripKey][]model.StopTime)
+	for _, st := range stopTimes {
+		if st.Canceled {
+			continue
+		}
+		key := tripKey{routeId: st.RouteId, frequencyId: st.FrequencyId, queue: st.Queue, dayOffset: st.DayOffset}
+		trips[key] = append(trips[key], st)
+		tt.names[st.StationId] = st.StationName
+	}
+
+	for key, stops := range trips {
+		sort.Slice(stops, func(i, j int) bool { return stops[i].Pos < stops[j].Pos })
+		for i := 1; i < len(stops); i++ {
+			tt.connections = append(tt.connections, connection{trip: key, from: stops[i-1], to: stops[i]})
+		}
+	}
+	sort.Slice(tt.connections, func(i, j int) bool {
+		a, b := tt.connections[i], tt.connections[j]
+		if a.from.Time != b.from.Time {
+			return a.from.Time < b.from.Time
+		}
+		return a.to.Time < b.to.Time
+	})
+
+	for _, t := range transfers {
+		fp := footpath{from: t.FromId, to: t.ToId, walk: t.WalkMinutes * 60}
+		tt.footpaths[t.FromId] = append(tt.footpaths[t.FromId], fp)
+		tt.footpathsTo[t.ToId] = append(tt.footpathsTo[t.ToId], fp)
+		tt.names[t.FromId] = t.FromName
+		tt.names[t.ToId] = t.ToName
+	}
+	return tt
+}
+
+// walkForward adds walking transfers from the stations improved by rides in
+// this round. Walks start from the ride labels only, so a walk is never
+// followed by another one.
+func (tt *timetable) walkForward(cur map[int]label, improved []int) {
+	sources := make(map[int]label, len(improved))
+	for _, id := range improved {
+		sources[id] = cur[id]
+	}
+	for _, id := range improved {
+		src := sources[id]
+		for _, fp := range tt.footpaths[id] {
+			t := src.time + fp.walk
+			if l, ok := cur[fp.to]; ok && l.time <= t {
+				continue
+			}
+			cur[fp.to] = label{time: t, round: src.round, board: src.board, alight: src.alight, walk: id, walkTime: fp.walk}
+		}
+	}
+}
+
+// walkBackward is the backward scan counterpart of walkForward.
+func (tt *timetable) walkBackward(cur map[int]label, improved []int) {
+	sources := make(map[int]label, len(improved))
+	for _, id := range improved {
+		sources[id] = cur[id]
+	}
+	for _, id := range improved {
+		src := sources[id]
+		for _, fp := range tt.footpathsTo[id] {
+			t := src.time - fp.walk
+			if l, ok := cur[fp.from]; ok && l.time >= t {
+				continue
+			}
+			cur[fp.from] = label{time: t, round: src.round, board: src.board, alight: src.alight, walk: id, walkTime: fp.walk}
+		}
+	}
+}
+
+// scan runs a round based connection scan: round k holds the earliest
+// arrival at every station using at most k trips. Connections departing
+// after until are ignored.
+func (tt *timetable) scan(fromId, departAt, until, maxLegs int) []map[int]label {
+	rounds := []map[int]label{
+		{fromId: {time: departAt, board: -1, alight: -1}},
+	}
+	tt.walkForward(rounds[0], []int{fromId})
+	start := sort.Search(len(tt.connections), func(i int) bool {
+		return tt.connections[i].from.Time >= departAt
+	})
+
+	for k := 1; k <= maxLegs; k++ {
+		prev := rounds[k-1]
+		cur := make(map[int]label, len(prev))
+		for id, l := range prev {
+			cur[id] = l
+		}
+		boarded := make(map[tripKey]int)
+		improved := make(map[int]bool)
+
+		for i := start; i < len(tt.connections); i++ {
+			c := tt.connections[i]
+			if c.from.Time > until {
+				break
+			}
+			boardIdx, ok := boarded[c.trip]
+			if !ok {
+				l, reached := prev[c.from.StationId]
+				if !reached || l.time > c.from.Time || tt.blocked[c.from.StationId] {
+					continue
+				}
+				boardIdx = i
+				boarded[c.trip] = i
+			}
+			if l, ok := cur[c.to.StationId]; (ok && l.time <= c.to.Time) || tt.blocked[c.to.StationId] {
+				continue
+			}
+			cur[c.to.StationId] = label{time: c.to.Time, round: k, board: boardIdx, alight: i}
+			improved[c.to.StationId] = true
+		}
+
+		if len(improved) == 0 {
+			break
+		}
+		tt.walkForward(cur, sortedIds(improved))
+		rounds = append(rounds, cur)
+	}
+	return rounds
+}
+
+// reachable lists every station, except the origin, that can be reached
+// from fromId no later than departAt+budget.
+func (tt *timetable) reachable(fromId, departAt, budget, maxLegs int) []model.Reachable {
+	rounds := tt.scan(fromId, departAt, departAt+budget, maxLegs)
+	best := rounds[len(rounds)-1]
+
+	items := make([]model.Reachable, 0)
+	for stationId, l := range best {
+		if stationId == fromId || l.time > departAt+budget {
+			continue
+		}
+		items = append(items, model.Reachable{
+			Station:   model.Place{Id: stationId, Name: tt.names[stationId]},
+			Arrival:   model.FormatClock(l.time),
+			Minutes:   (l.time - departAt) / 60,
+			Transfers: max(l.round-1, 0),
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Minutes != items[j].Minutes {
+			return items[i].Minutes < items[j].Minutes
+		}
+		return items[i].Station.Name < items[j].Station.Name
+	})
+	return items
+}
+
+// scanBackward mirrors scan: round k holds the latest departure from every
+// station that still reaches toId by arriveBy using at most k trips.
+func (tt *timetable) scanBackward(toId, arriveBy, maxLegs int) []map[int]label {
+	rounds := []map[int]label{
+		{toId: {time: arriveBy, board: -1, alight: -1}},
+	}
+	tt.walkBackward(rounds[0], []int{toId})
+	order := make([]int, 0, len(tt.connections))
+	for i, c := range tt.connections {
+		if c.to.Time <= arriveBy {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return tt.connections[order[i]].to.Time > tt.connections[order[j]].to.Time
+	})
+
+	for k := 1; k <= maxLegs; k++ {
+		prev := rounds[k-1]
+		cur := make(map[int]label, len(prev))
+		for id, l := range prev {
+			cur[id] = l
+		}
+		alighted := make(map[tripKey]int)
+		improved := make(map[int]bool)
+
+		for _, i := range order {
+			c := tt.connections[i]
+			alightIdx, ok := alighted[c.trip]
+			if !ok {
+				l, reached := prev[c.to.StationId]
+				if !reached || l.time < c.to.Time || tt.blocked[c.to.StationId] {
+					continue
+				}
+				alightIdx = i
+				alighted[c.trip] = i
+			}
+			if l, ok := cur[c.from.StationId]; (ok && l.time >= c.from.Time) || tt.blocked[c.from.StationId] {
+				continue
+			}
+			cur[c.from.StationId] = label{time: c.from.Time, round: k, board: i, alight: alightIdx}
+			improved[c.from.StationId] = true
+		}
+
+		if len(improved) == 0 {
+			break
+		}
+		tt.walkBackward(cur, sortedIds(improved))
+		rounds = append(rounds, cur)
+	}
+	return rounds
+}
+
+// ridesForward rebuilds the trips and walks used to reach toId in the given
+// round of a forward scan.
+func (tt *timetable) ridesForward(rounds []map[int]label, toId, round int) []ride {
+	var rides []ride
+	stationId := toId
+	for {
+		l := rounds[round][stationId]
+		if l.walk != 0 {
+			rides = append([]ride{tt.walkRide(l.walk, stationId, l.time-l.walkTime, l.time)}, rides...)
+		}
+		if l.board < 0 {
+			break
+		}
+		r := ride{board: tt.connections[l.board].from, alight: tt.connections[l.alight].to}
+		rides = append([]ride{r}, rides...)
+		stationId = r.board.StationId
+		round = l.round - 1
+	}
+	return rides
+}
+
+// ridesBackward rebuilds the trips and walks used to leave fromId in the
+// given round of a backward scan.
+func (tt *timetable) ridesBackward(rounds []map[int]label, fromId, round int) []ride {
+	var rides []ride
+	stationId := fromId
+	for {
+		l := rounds[round][stationId]
+		if l.walk != 0 {
+			rides = append(rides, tt.walkRide(stationId, l.walk, l.time, l.time+l.walkTime))
+		}
+		if l.board < 0 {
+			break
+		}
+		r := ride{board: tt.connections[l.board].from, alight: tt.connections[l.alight].to}
+		rides = append(rides, r)
+		stationId = r.alight.StationId
+		round = l.round - 1
+	}
+	return rides
+}
+
+func (tt *timetable) walkRide(fromId, toId, departure, arrival int) ride {
+	return ride{
+		board:  model.StopTime{StationId: fromId, StationName: tt.names[fromId], Time: departure},
+		alight: model.StopTime{StationId: toId, StationName: tt.names[toId], Time: arrival},
+		walk:   true,
+	}
+}
+
+func sortedIds(set map[int]bool) []int {
+	ids := make([]int, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func newTimedLeg(r ride) model.Leg {
+	if r.walk {
+		return model.Leg{
+			Walk:        true,
+			Board:       model.Place{Id: r.board.StationId, Name: r.board.StationName},
+			Alight:      model.Place{Id: r.alight.StationId, Name: r.alight.StationName},
+			WalkMinutes: (r.alight.Time - r.board.Time) / 60,
+			Departure:   model.FormatClock(r.board.Time),
+			Arrival:     model.FormatClock(r.alight.Time),
+		}
+	}
+	queue := r.board.Queue
+	leg := model.Leg{
+		RouteId:     r.board.RouteId,
+		RouteName:   r.board.RouteName,
+		Board:       model.Place{Id: r.board.StationId, Name: r.board.StationName},
+		Alight:      model.Place{Id: r.alight.StationId, Name: r.alight.StationName},
+		Stops:       r.alight.Pos - r.board.Pos,
+		TripId:      r.board.TripId,
+		FrequencyId: r.board.FrequencyId,
+		Queue:       &queue,
+		Departure:   model.FormatClock(r.board.Time),
+		Arrival:     model.FormatClock(r.alight.Time),
+	}
+	if r.board.Predicted != nil {
+		leg.PredictedDeparture = model.FormatClock(*r.board.Predicted)
+	}
+	if r.alight.Predicted != nil {
+		leg.PredictedArrival = model.FormatClock(*r.alight.Predicted)
+	}
+	return leg
+}
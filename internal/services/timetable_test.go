@@ -0,0 +1,209 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// tripTimes returns a trip of the route calling at the stations ten minutes
+// apart from start.
+func tripTimes(routeId, queue, start int, stations ...int) []model.StopTime {
+	stops := make([]model.StopTime, 0, len(stations))
+	for pos, id := range stations {
+		stops = append(stops, model.StopTime{
+			RouteId:     routeId,
+			TripId:      routeId*100 + queue,
+			Queue:       queue,
+			StationId:   id,
+			StationName: "station",
+			Pos:         pos,
+			Time:        start + pos*600,
+		})
+	}
+	return stops
+}
+
+func clock(t *testing.T, v string) int {
+	t.Helper()
+	sec, err := model.ParseClock(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sec
+}
+
+func joinTrips(trips ...[]model.StopTime) []model.StopTime {
+	var all []model.StopTime
+	for _, trip := range trips {
+		all = append(all, trip...)
+	}
+	return all
+}
+
+func TestScan(t *testing.T) {
+	h := func(v string) int { return clock(t, v) }
+	tests := []struct {
+		name      string
+		stopTimes []model.StopTime
+		transfers []model.Transfer
+		from, to  int
+		departAt  string
+		maxLegs   int
+		// arrival is empty when to is not reached
+		arrival string
+		round   int
+	}{
+		{
+			name:      "direct trip",
+			stopTimes: tripTimes(1, 0, h("08:00"), 1, 2, 3),
+			from:      1, to: 3, departAt: "07:50", maxLegs: 3,
+			arrival: "08:20:00", round: 1,
+		},
+		{
+			name:      "trip already left",
+			stopTimes: tripTimes(1, 0, h("08:00"), 1, 2, 3),
+			from:      1, to: 3, departAt: "08:01", maxLegs: 3,
+		},
+		{
+			name: "earliest of two trips",
+			stopTimes: joinTrips(
+				tripTimes(1, 0, h("08:00"), 1, 2, 3),
+				tripTimes(1, 1, h("08:30"), 1, 2, 3),
+			),
+			from: 1, to: 3, departAt: "08:05", maxLegs: 3,
+			arrival: "08:50:00", round: 1,
+		},
+		{
+			name: "transfer",
+			stopTimes: joinTrips(
+				tripTimes(1, 0, h("08:00"), 1, 2),
+				tripTimes(2, 0, h("08:15"), 2, 4),
+			),
+			from: 1, to: 4, departAt: "07:50", maxLegs: 2,
+			arrival: "08:25:00", round: 2,
+		},
+		{
+			name: "transfer over the limit",
+			stopTimes: joinTrips(
+				tripTimes(1, 0, h("08:00"), 1, 2),
+				tripTimes(2, 0, h("08:15"), 2, 4),
+			),
+			from: 1, to: 4, departAt: "07:50", maxLegs: 1,
+		},
+		{
+			name: "missed transfer",
+			stopTimes: joinTrips(
+				tripTimes(1, 0, h("08:00"), 1, 2),
+				tripTimes(2, 0, h("08:05"), 2, 4),
+			),
+			from: 1, to: 4, departAt: "07:50", maxLegs: 2,
+		},
+		{
+			name: "walking transfer",
+			stopTimes: joinTrips(
+				tripTimes(1, 0, h("08:00"), 1, 2),
+				tripTimes(3, 0, h("08:20"), 5, 6),
+			),
+			transfers: []model.Transfer{{FromId: 2, ToId: 5, WalkMinutes: 5}},
+			from:      1, to: 6, departAt: "07:50", maxLegs: 2,
+			arrival: "08:30:00", round: 2,
+		},
+		{
+			name: "walk too long for the transfer",
+			stopTimes: joinTrips(
+				tripTimes(1, 0, h("08:00"), 1, 2),
+				tripTimes(3, 0, h("08:20"), 5, 6),
+			),
+			transfers: []model.Transfer{{FromId: 2, ToId: 5, WalkMinutes: 15}},
+			from:      1, to: 6, departAt: "07:50", maxLegs: 2,
+		},
+		{
+			name:      "walk to the destination",
+			stopTimes: tripTimes(1, 0, h("08:00"), 1, 2),
+			transfers: []model.Transfer{{FromId: 2, ToId: 7, WalkMinutes: 4}},
+			from:      1, to: 7, departAt: "07:50", maxLegs: 1,
+			arrival: "08:14:00", round: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTimetable(tc.stopTimes, tc.transfers)
+			rounds := tt.scan(tc.from, clock(t, tc.departAt), h("47:59"), tc.maxLegs)
+			l, ok := rounds[len(rounds)-1][tc.to]
+			if tc.arrival == "" {
+				if ok {
+					t.Fatalf("station %d reached at %s, want unreachable", tc.to, model.FormatClock(l.time))
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("station %d not reached", tc.to)
+			}
+			if got := model.FormatClock(l.time); got != tc.arrival || l.round != tc.round {
+				t.Errorf("arrival %s in round %d, want %s in round %d", got, l.round, tc.arrival, tc.round)
+			}
+		})
+	}
+}
+
+func TestScanBackward(t *testing.T) {
+	h := func(v string) int { return clock(t, v) }
+	stopTimes := joinTrips(
+		tripTimes(1, 0, h("08:00"), 1, 2),
+		tripTimes(1, 1, h("08:20"), 1, 2),
+		tripTimes(2, 0, h("08:35"), 2, 4),
+	)
+	tests := []struct {
+		name      string
+		arriveBy  string
+		maxLegs   int
+		departure string
+	}{
+		{name: "latest trips", arriveBy: "08:50", maxLegs: 2, departure: "08:20:00"},
+		{name: "last trip too late", arriveBy: "08:44", maxLegs: 2},
+		{name: "transfer over the limit", arriveBy: "08:50", maxLegs: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTimetable(stopTimes, nil)
+			rounds := tt.scanBackward(4, clock(t, tc.arriveBy), tc.maxLegs)
+			l, ok := rounds[len(rounds)-1][1]
+			if tc.departure == "" {
+				if ok {
+					t.Fatalf("station 1 left at %s, want unreachable", model.FormatClock(l.time))
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("station 1 not reached")
+			}
+			if got := model.FormatClock(l.time); got != tc.departure {
+				t.Errorf("departure %s, want %s", got, tc.departure)
+			}
+		})
+	}
+}
+
+func TestEarliestArrivalLegs(t *testing.T) {
+	h := func(v string) int { return clock(t, v) }
+	tt := newTimetable(joinTrips(
+		tripTimes(1, 0, h("08:00"), 1, 2),
+		tripTimes(3, 0, h("08:20"), 5, 6),
+	), []model.Transfer{{FromId: 2, ToId: 5, WalkMinutes: 5}})
+	departAt := h("07:50")
+
+	journeys := tt.earliestArrival(model.JourneyQuery{FromId: 1, ToId: 6, MaxTransfers: 2, DepartAt: &departAt})
+	if len(journeys) != 1 {
+		t.Fatalf("got %d journeys, want 1", len(journeys))
+	}
+	legs := journeys[0].Legs
+	if len(legs) != 3 || legs[0].Walk || !legs[1].Walk || legs[2].Walk {
+		t.Fatalf("want ride, walk, ride; got %+v", legs)
+	}
+	if legs[1].WalkMinutes != 5 || legs[2].Arrival != "08:30:00" {
+		t.Errorf("walk %d min, arrival %s", legs[1].WalkMinutes, legs[2].Arrival)
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// SuggestTransfers proposes walking transfers, both ways, between stations
+// at most radius meters apart that have no transfer yet. radius <= 0 means
+// the configured walk radius.
+func (s *busService) SuggestTransfers(ctx context.Context, radius float64) ([]model.Transfer, error) {
+	if radius <= 0 {
+		radius = s.cfg.WalkRadius
+	}
+
+	stations, err := s.repository.GetStations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transfers, err := s.repository.GetTransfers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[[2]int]bool, len(transfers))
+	for _, t := range transfers {
+		exists[[2]int{t.FromId, t.ToId}] = true
+	}
+
+	located := make([]*model.Station, 0, len(stations))
+	for _, item := range stations {
+		if st, ok := item.(*model.Station); ok && st.Lat != nil && st.Lon != nil {
+			located = append(located, st)
+		}
+	}
+
+	items := make([]model.Transfer, 0)
+	for _, from := range located {
+		for _, to := range located {
+			if from.Id == to.Id || exists[[2]int{from.Id, to.Id}] {
+				continue
+			}
+			distance := model.Distance(*from.Lat, *from.Lon, *to.Lat, *to.Lon)
+			if distance > radius {
+				continue
+			}
+			items = append(items, model.Transfer{
+				FromId:      from.Id,
+				FromName:    from.Name,
+				ToId:        to.Id,
+				ToName:      to.Name,
+				WalkMinutes: max(int(math.Ceil(distance/s.cfg.WalkSpeed)), 1),
+			})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].WalkMinutes != items[j].WalkMinutes {
+			return items[i].WalkMinutes < items[j].WalkMinutes
+		}
+		if items[i].FromName != items[j].FromName {
+			return items[i].FromName < items[j].FromName
+		}
+		return items[i].ToName < items[j].ToName
+	})
+	return items, nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+func (s *busService) GenerateTrips(ctx context.Context, routeId int, pattern model.TripPattern) ([]model.Trip, error) {
+	if len(pattern.Departures) == 0 {
+		return nil, errors.New("departures are required")
+	}
+	if len(pattern.Offsets) == 0 {
+		return nil, errors.New("offsets are required")
+	}
+
+	routeStations, err := s.repository.GetRouteStations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byPos := make(map[int]model.RouteStation)
+	for _, rs := range routeStations {
+		if rs.RouteId == routeId {
+			byPos[rs.Pos] = rs
+		}
+	}
+
+	offsets := make([]model.PosOffset, len(pattern.Offsets))
+	copy(offsets, pattern.Offsets)
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i].Pos < offsets[j].Pos })
+	for i, o := range offsets {
+		if _, ok := byPos[o.Pos]; !ok {
+			return nil, fmt.Errorf("route %d has no station at pos %d", routeId, o.Pos)
+		}
+		if o.Minutes < 0 {
+			return nil, fmt.Errorf("offset at pos %d is negative", o.Pos)
+		}
+		if i > 0 && o.Pos == offsets[i-1].Pos {
+			return nil, fmt.Errorf("pos %d has more than one offset", o.Pos)
+		}
+		if i > 0 && o.Minutes < offsets[i-1].Minutes {
+			return nil, fmt.Errorf("offset at pos %d is earlier than at pos %d", o.Pos, offsets[i-1].Pos)
+		}
+	}
+
+	// every departure is checked before anything is written
+	last := offsets[len(offsets)-1].Minutes * 60
+	starts := make([]int, 0, len(pattern.Departures))
+	for _, departure := range pattern.Departures {
+		start, err := model.ParseClock(departure)
+		if err != nil {
+			return nil, err
+		}
+		if start+last >= model.MaxClock {
+			return nil, fmt.Errorf("trip leaving at %s ends after 47:59:59", departure)
+		}
+		starts = append(starts, start)
+	}
+
+	trips := make([]*model.Trip, 0, len(starts))
+	for _, start := range starts {
+		trip := &model.Trip{
+			RouteId:    routeId,
+			CalendarId: pattern.CalendarId,
+			Headsign:   pattern.Headsign,
+			Wheelchair: pattern.Wheelchair,
+			StopTimes:  make([]model.TripStopTime, 0, len(offsets)),
+		}
+		for _, o := range offsets {
+			trip.StopTimes = append(trip.StopTimes, model.TripStopTime{
+				StationId: byPos[o.Pos].StationId,
+				Time:      model.FormatClock(start + o.Minutes*60),
+			})
+		}
+		trips = append(trips, trip)
+	}
+
+	if err = s.repository.CreateTrips(ctx, trips); err != nil {
+		return nil, err
+	}
+
+	items := make([]model.Trip, 0, len(trips))
+	for _, trip := range trips {
+		items = append(items, *trip)
+	}
+	return items, nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// tripsRepository serves one route over stations 1 to 3 and counts writes.
+type tripsRepository struct {
+	model.Repository
+	created int
+}
+
+func (r *tripsRepository) GetRouteStations(context.Context) ([]model.RouteStation, error) {
+	return []model.RouteStation{
+		{Id: 1, RouteId: 1, StationId: 1, Pos: 0},
+		{Id: 2, RouteId: 1, StationId: 2, Pos: 1},
+		{Id: 3, RouteId: 1, StationId: 3, Pos: 2},
+	}, nil
+}
+
+func (r *tripsRepository) CreateTrips(_ context.Context, items []*model.Trip) error {
+	r.created += len(items)
+	return nil
+}
+
+func TestGenerateTrips(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern model.TripPattern
+		trips   int
+	}{
+		{
+			name: "valid pattern",
+			pattern: model.TripPattern{
+				Departures: []string{"08:00", "23:50"},
+				Offsets:    []model.PosOffset{{Pos: 0, Minutes: 0}, {Pos: 2, Minutes: 20}, {Pos: 1, Minutes: 10}},
+			},
+			trips: 2,
+		},
+		{
+			name: "duplicate pos",
+			pattern: model.TripPattern{
+				Departures: []string{"08:00"},
+				Offsets:    []model.PosOffset{{Pos: 0, Minutes: 0}, {Pos: 1, Minutes: 10}, {Pos: 1, Minutes: 10}},
+			},
+		},
+		{
+			name: "negative minutes",
+			pattern: model.TripPattern{
+				Departures: []string{"08:00"},
+				Offsets:    []model.PosOffset{{Pos: 0, Minutes: -5}, {Pos: 1, Minutes: 10}},
+			},
+		},
+		{
+			name: "last departure ends after 47:59:59",
+			pattern: model.TripPattern{
+				Departures: []string{"08:00", "47:50"},
+				Offsets:    []model.PosOffset{{Pos: 0, Minutes: 0}, {Pos: 1, Minutes: 10}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &tripsRepository{}
+			s := &busService{repository: repo}
+			trips, err := s.GenerateTrips(context.Background(), 1, tc.pattern)
+			if tc.trips == 0 {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				if repo.created != 0 {
+					t.Errorf("%d trips written before the error", repo.created)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(trips) != tc.trips || repo.created != tc.trips {
+				t.Fatalf("got %d trips, %d written, want %d", len(trips), repo.created, tc.trips)
+			}
+			if got := trips[1].StopTimes[2].Time; got != "24:10:00" {
+				t.Errorf("last stop at %s, want 24:10:00", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+func viaStops(q model.JourneyQuery) []int {
+	stops := []int{q.FromId}
+	for _, id := range q.ViaIds {
+		if id != stops[len(stops)-1] {
+			stops = append(stops, id)
+		}
+	}
+	if q.ToId != stops[len(stops)-1] {
+		stops = append(stops, q.ToId)
+	}
+	return stops
+}
+
+// earliestArrivalVia chains searches between consecutive via stations and
+// takes the fastest option of every part.
+func (tt *timetable) earliestArrivalVia(q model.JourneyQuery) []model.Journey {
+	stops := viaStops(q)
+	departAt := *q.DepartAt
+	var rides []ride
+	for i := 1; i < len(stops); i++ {
+		front := paretoFront(tt.forwardOptions(stops[i-1], stops[i], departAt, q.MaxTransfers+1), byArrival)
+		if len(front) == 0 {
+			return make([]model.Journey, 0)
+		}
+		rides = append(rides, front[0].rides...)
+		departAt = front[0].arrival
+	}
+	return viaJourneys(rides, q.MaxTransfers, labelEarliestArrival)
+}
+
+// latestDepartureVia is the arrive_by counterpart of earliestArrivalVia, it
+// chains the parts from the destination backwards.
+func (tt *timetable) latestDepartureVia(q model.JourneyQuery) []model.Journey {
+	stops := viaStops(q)
+	arriveBy := *q.ArriveBy
+	var rides []ride
+	for i := len(stops) - 1; i > 0; i-- {
+		front := paretoFront(tt.backwardOptions(stops[i-1], stops[i], arriveBy, q.MaxTransfers+1), byDeparture)
+		if len(front) == 0 {
+			return make([]model.Journey, 0)
+		}
+		rides = append(append([]ride{}, front[0].rides...), rides...)
+		arriveBy = front[0].departure
+	}
+	return viaJourneys(rides, q.MaxTransfers, labelLatestDeparture)
+}
+
+// viaJourneys joins rides that stay on the same trip through a via station.
+func viaJourneys(rides []ride, maxTransfers int, primary string) []model.Journey {
+	if len(rides) == 0 {
+		return make([]model.Journey, 0)
+	}
+	merged := []ride{rides[0]}
+	for _, r := range rides[1:] {
+		last := &merged[len(merged)-1]
+		if !last.walk && !r.walk && last.board.RouteId == r.board.RouteId && last.board.FrequencyId == r.board.FrequencyId &&
+			last.board.Queue == r.board.Queue && last.alight.StationId == r.board.StationId {
+			last.alight = r.alight
+			continue
+		}
+		merged = append(merged, r)
+	}
+	o := newOption(merged)
+	if o.transfers > maxTransfers {
+		return make([]model.Journey, 0)
+	}
+	return labelledJourneys([]option{o}, primary)
+}
+
+// avoid splits every trip at its stops at one of the stations: the
+// connections to and from them are dropped and the rest of the trip becomes
+// a separate segment, so no trip is ridden through them but it can still be
+// boarded after them.
+func (tt *timetable) avoid(stationIds []int) {
+	if len(stationIds) == 0 {
+		return
+	}
+	avoided := make(map[int]bool, len(stationIds))
+	for _, id := range stationIds {
+		avoided[id] = true
+	}
+	// stops holds the positions of the avoided stops of each trip
+	stops := make(map[tripKey][]int)
+	for _, c := range tt.connections {
+		if avoided[c.from.StationId] {
+			stops[c.trip] = append(stops[c.trip], c.from.Pos)
+		}
+		if avoided[c.to.StationId] {
+			stops[c.trip] = append(stops[c.trip], c.to.Pos)
+		}
+	}
+	connections := tt.connections[:0]
+	for _, c := range tt.connections {
+		if avoided[c.from.StationId] || avoided[c.to.StationId] {
+			continue
+		}
+		for _, pos := range stops[c.trip] {
+			if pos < c.from.Pos {
+				c.trip.segment++
+			}
+		}
+		connections = append(connections, c)
+	}
+	tt.connections = connections
+
+	for id := range avoided {
+		delete(tt.footpaths, id)
+		delete(tt.footpathsTo, id)
+	}
+	for id, fps := range tt.footpaths {
+		tt.footpaths[id] = avoidFootpaths(fps, avoided)
+	}
+	for id, fps := range tt.footpathsTo {
+		tt.footpathsTo[id] = avoidFootpaths(fps, avoided)
+	}
+}
+
+func avoidFootpaths(fps []footpath, avoided map[int]bool) []footpath {
+	kept := make([]footpath, 0, len(fps))
+	for _, fp := range fps {
+		if !avoided[fp.from] && !avoided[fp.to] {
+			kept = append(kept, fp)
+		}
+	}
+	return kept
+}
+
+func (n *network) findJourneysVia(q model.JourneyQuery) []model.Journey {
+	stops := viaStops(q)
+	var legs []model.Leg
+	for i := 1; i < len(stops); i++ {
+		found := n.findJourneys(model.JourneyQuery{FromId: stops[i-1], ToId: stops[i], MaxTransfers: q.MaxTransfers})
+		if len(found) == 0 {
+			return make([]model.Journey, 0)
+		}
+		legs = append(legs, found[0].Legs...)
+	}
+
+	merged := make([]model.Leg, 0, len(legs))
+	for _, leg := range legs {
+		leg.Transfer = nil
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			if !last.Walk && !leg.Walk && last.RouteId == leg.RouteId && last.Alight.Id == leg.Board.Id {
+				last.Alight = leg.Alight
+				last.Stops += leg.Stops
+				continue
+			}
+		}
+		merged = append(merged, leg)
+	}
+	journey := newJourney(merged)
+	if journey.Transfers > q.MaxTransfers {
+		return make([]model.Journey, 0)
+	}
+	return []model.Journey{journey}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+func TestAvoid(t *testing.T) {
+	h := func(v string) int { return clock(t, v) }
+	tests := []struct {
+		name      string
+		stopTimes []model.StopTime
+		avoidIds  []int
+		from      int
+		journeys  int
+	}{
+		{
+			name:      "avoided middle stop",
+			stopTimes: tripTimes(1, 0, h("08:00"), 1, 2, 3, 4, 5),
+			avoidIds:  []int{3},
+			from:      1,
+		},
+		{
+			name:      "boarding after the avoided stop",
+			stopTimes: tripTimes(1, 0, h("08:00"), 1, 2, 3, 4, 5),
+			avoidIds:  []int{3},
+			from:      4,
+			journeys:  1,
+		},
+		{
+			name:      "avoided stop after the destination",
+			stopTimes: tripTimes(1, 0, h("08:00"), 1, 2, 5, 3),
+			avoidIds:  []int{3},
+			from:      1,
+			journeys:  1,
+		},
+		{
+			name: "detour around the avoided stop",
+			stopTimes: joinTrips(
+				tripTimes(1, 0, h("08:00"), 1, 2, 3, 4, 5),
+				tripTimes(2, 0, h("08:15"), 2, 6, 5),
+			),
+			avoidIds: []int{3},
+			from:     1,
+			journeys: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newTimetable(tc.stopTimes, nil)
+			tt.avoid(tc.avoidIds)
+			departAt := h("07:50")
+			journeys := tt.earliestArrival(model.JourneyQuery{FromId: tc.from, ToId: 5, MaxTransfers: 2, DepartAt: &departAt})
+			if len(journeys) != tc.journeys {
+				t.Fatalf("got %d journeys, want %d", len(journeys), tc.journeys)
+			}
+			pos := make(map[int]int)
+			for _, st := range tc.stopTimes {
+				if st.RouteId == 1 {
+					pos[st.StationId] = st.Pos
+				}
+			}
+			for _, j := range journeys {
+				for _, leg := range j.Legs {
+					if leg.RouteId == 1 && pos[leg.Board.Id] < pos[3] && pos[leg.Alight.Id] > pos[3] {
+						t.Errorf("rode route 1 from station %d to %d through 3", leg.Board.Id, leg.Alight.Id)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+// inaccessibleStations lists the stations a wheelchair user can not board
+// or alight at, stations with unknown accessibility are included.
+func (s *busService) inaccessibleStations(ctx context.Context) (map[int]bool, error) {
+	stations, err := s.repository.GetStations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	blocked := make(map[int]bool)
+	for _, item := range stations {
+		if st, ok := item.(*model.Station); ok && st.Wheelchair != model.WheelchairAccessible {
+			blocked[st.Id] = true
+		}
+	}
+	return blocked, nil
+}
+
+// stepFree keeps only the trips marked accessible. Trips still pass through
+// blocked stations but are never boarded or left there.
+func (tt *timetable) stepFree(blocked map[int]bool) {
+	connections := tt.connections[:0]
+	for _, c := range tt.connections {
+		if c.from.Wheelchair == model.WheelchairAccessible {
+			connections = append(connections, c)
+		}
+	}
+	tt.connections = connections
+	tt.blocked = blocked
+
+	for id, fps := range tt.footpaths {
+		tt.footpaths[id] = avoidFootpaths(fps, blocked)
+	}
+	for id, fps := range tt.footpathsTo {
+		tt.footpathsTo[id] = avoidFootpaths(fps, blocked)
+	}
+}
+
+// accessibleRoutes lists the routes with at least one accessible trip or
+// frequency.
+func (s *busService) accessibleRoutes(ctx context.Context) (map[int]bool, error) {
+	stopTimes, err := s.repository.GetStopTimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	frequencies, err := s.repository.GetFrequencies(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	routes := make(map[int]bool)
+	for _, st := range stopTimes {
+		if st.Wheelchair == model.WheelchairAccessible {
+			routes[st.RouteId] = true
+		}
+	}
+	for _, f := range frequencies {
+		if f.Wheelchair == model.WheelchairAccessible {
+			routes[f.RouteId] = true
+		}
+	}
+	return routes, nil
+}
+
+// stepFree drops the routes without an accessible trip, the network does
+// not know which trip is taken so any accessible one will do.
+func (n *network) stepFree(blocked map[int]bool, routes map[int]bool) {
+	n.blocked = blocked
+	for id := range n.routes {
+		if !routes[id] {
+			delete(n.routes, id)
+		}
+	}
+	for id, stops := range n.byStation {
+		kept := stops[:0]
+		for _, rs := range stops {
+			if routes[rs.RouteId] {
+				kept = append(kept, rs)
+			}
+		}
+		n.byStation[id] = kept
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/alexeybs90/go_bus_routes/internal/model"
+)
+
+func accessible(stops []model.StopTime) []model.StopTime {
+	for i := range stops {
+		stops[i].Wheelchair = model.WheelchairAccessible
+	}
+	return stops
+}
+
+func TestTimetableStepFree(t *testing.T) {
+	h := func(v string) int { return clock(t, v) }
+	tests := []struct {
+		name      string
+		stopTimes []model.StopTime
+		blocked   map[int]bool
+		// departure from station 1 arriving at 3, empty when there is none
+		departure string
+	}{
+		{
+			name:      "accessible trip",
+			stopTimes: accessible(tripTimes(1, 0, h("08:00"), 1, 2, 3)),
+			departure: "08:00:00",
+		},
+		{
+			name: "inaccessible trip skipped",
+			stopTimes: joinTrips(
+				tripTimes(1, 0, h("08:00"), 1, 2, 3),
+				accessible(tripTimes(1, 1, h("08:30"), 1, 2, 3)),
+			),
+			departure: "08:30:00",
+		},
+		{
+			name:      "ridden through a blocked station",
+			stopTimes: accessible(tripTimes(1, 0, h("08:00"), 1, 2, 3)),
+			blocked:   map[int]bool{2: true},
+			departure: "08:00:00",
+		},
+		{
+			name:      "blocked boarding station",
+			stopTimes: accessible(tripTimes(1, 0, h("08:00"), 1, 2, 3)),
+			blocked:   map[int]bool{1: true},
+		},
+		{
+			name:      "blocked destination",
+			stopTimes: accessible(tripTimes(1, 0, h("08:00"), 1, 2, 3)),
+			blocked:   map[int]bool{3: true},
+		},
+		{
+			name: "no change at a blocked station",
+			stopTimes: joinTrips(
+				accessible(tripTimes(1, 0, h("08:00"), 1, 2)),
+				accessible(tripTimes(2, 0, h("08:20"), 2, 3)),
+			),
+			blocked: map[int]bool{2: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			departAt, arriveBy := h("07:50"), h("09:30")
+			for _, mode := range []string{"depart_at", "arrive_by"} {
+				tt := newTimetable(tc.stopTimes, nil)
+				tt.stepFree(tc.blocked)
+				q := model.JourneyQuery{FromId: 1, ToId: 3, MaxTransfers: 1}
+				var journeys []model.Journey
+				if mode == "depart_at" {
+					q.DepartAt = &departAt
+					journeys = tt.earliestArrival(q)
+				} else {
+					q.ArriveBy = &arriveBy
+					journeys = tt.latestDeparture(q)
+				}
+				if tc.departure == "" {
+					if len(journeys) != 0 {
+						t.Errorf("%s: got %d journeys, want none", mode, len(journeys))
+					}
+					continue
+				}
+				if len(journeys) != 1 {
+					t.Fatalf("%s: got %d journeys, want 1", mode, len(journeys))
+				}
+				if got := journeys[0].Legs[0].Departure; got != tc.departure {
+					t.Errorf("%s: departure %s, want %s", mode, got, tc.departure)
+				}
+			}
+		})
+	}
+}
+
+func TestNetworkStepFree(t *testing.T) {
+	routeStations := func(routeId int, stations ...int) []model.RouteStation {
+		items := make([]model.RouteStation, 0, len(stations))
+		for pos, id := range stations {
+			items = append(items, model.RouteStation{RouteId: routeId, StationId: id, Pos: pos})
+		}
+		return items
+	}
+	stations := append(routeStations(1, 1, 2, 3), routeStations(2, 1, 4, 3)...)
+	tests := []struct {
+		name    string
+		blocked map[int]bool
+		routes  map[int]bool
+		want    []int
+	}{
+		{name: "both routes accessible", routes: map[int]bool{1: true, 2: true}, want: []int{1, 2}},
+		{name: "route without accessible trips", routes: map[int]bool{2: true}, want: []int{2}},
+		{name: "no accessible route", routes: map[int]bool{}},
+		{name: "blocked destination", blocked: map[int]bool{3: true}, routes: map[int]bool{1: true, 2: true}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			n := newNetwork(stations, nil)
+			n.stepFree(tc.blocked, tc.routes)
+			journeys := n.findJourneys(model.JourneyQuery{FromId: 1, ToId: 3, MaxTransfers: 0})
+			got := make(map[int]bool)
+			for _, j := range journeys {
+				got[j.Legs[0].RouteId] = true
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("routes %v, want %v", got, tc.want)
+			}
+			for _, id := range tc.want {
+				if !got[id] {
+					t.Errorf("routes %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}